@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// bundle is a directory with everything needed to generate configs without network access.
+// It is filled by the prefetch command, which generates the configs once and keeps everything
+// fetched on the way, and read by -offline:
+//
+//   - toolchains: the terraform and OpenTofu binaries, in the layout of -toolchain-cache-dir
+//   - providers: the provider plugins, in the unpacked layout of a terraform filesystem mirror
+//   - npm: the npm cache with the tarballs of every npm package
+//   - metadata: the responses of the npm and deps.dev APIs by url
+type bundle struct {
+	// Dir is the absolute path of the bundle dir
+	Dir string
+	// Offline only reads from the bundle, otherwise everything fetched is added to it
+	Offline bool
+}
+
+// newBundle returns the bundle of the dir
+func newBundle(dir string, offline bool) (*bundle, error) {
+	if dir == "" {
+		return nil, errors.New("-bundle is required")
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolve bundle dir %q", dir)
+	}
+	return &bundle{Dir: abs, Offline: offline}, nil
+}
+
+// offline returns true if everything must be read from the bundle, b may be nil
+func (b *bundle) offline() bool {
+	return b != nil && b.Offline
+}
+
+func (b *bundle) toolchainsDir() string { return filepath.Join(b.Dir, "toolchains") }
+
+func (b *bundle) providersDir() string { return filepath.Join(b.Dir, "providers") }
+
+func (b *bundle) npmCacheDir() string { return filepath.Join(b.Dir, "npm") }
+
+// metadataPath returns the path of the response of the url in the bundle
+func (b *bundle) metadataPath(u *url.URL) string {
+	return filepath.Join(b.Dir, "metadata", u.Host, url.PathEscape(u.EscapedPath())+".json")
+}
+
+// init creates the dirs of the bundle, the providers dir must exist for terraform to use it
+func (b *bundle) init() error {
+	for _, dir := range []string{b.toolchainsDir(), b.providersDir(), b.npmCacheDir()} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.Wrap(err, "create bundle dir")
+		}
+	}
+	return nil
+}
+
+// check returns an error if the dir is not a bundle filled by prefetch
+func (b *bundle) check() error {
+	for _, dir := range []string{b.toolchainsDir(), b.providersDir(), b.npmCacheDir()} {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return errors.Newf("%q is not a bundle, run the prefetch command to create it", b.Dir)
+		}
+	}
+	return nil
+}
+
+// environ returns environ with npm and terraform using the bundle. npm installs packages from
+// the npm cache of the bundle, and terraform adds every provider it installs to the bundle.
+// Offline, the providers are installed from the bundle by the CLI config instead, see cliConfig.
+func (b *bundle) environ(environ []string) []string {
+	if environ == nil {
+		environ = os.Environ()
+	}
+	environ = append(environ,
+		"npm_config_cache="+b.npmCacheDir(),
+		// disable the update checks of cdktf and terraform
+		"CHECKPOINT_DISABLE=1",
+	)
+	if b.Offline {
+		return append(environ, "npm_config_offline=true")
+	}
+	return append(environ, "TF_PLUGIN_CACHE_DIR="+b.providersDir())
+}
+
+// getJSON decodes the JSON response of the url into v. With a bundle, the response
+// is read from the bundle offline, and added to the bundle otherwise. b may be nil.
+func getJSON(ctx context.Context, b *bundle, rawURL string, v any) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return errors.Wrapf(err, "parse url %q", rawURL)
+	}
+
+	var body []byte
+	if b.offline() {
+		body, err = os.ReadFile(b.metadataPath(u))
+		if err != nil {
+			return errors.Wrapf(err, "%s is not in the bundle, run prefetch to add it", rawURL)
+		}
+	} else {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return errors.Wrap(err, "create request")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return errors.Wrapf(err, "GET %s", rawURL)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return errors.Newf("GET %s: unexpected status %s", rawURL, resp.Status)
+		}
+		if body, err = io.ReadAll(resp.Body); err != nil {
+			return errors.Wrapf(err, "read response of %s", rawURL)
+		}
+		if b != nil {
+			path := b.metadataPath(u)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return errors.Wrap(err, "create bundle metadata dir")
+			}
+			if err := os.WriteFile(path, body, 0644); err != nil {
+				return errors.Wrap(err, "add response to bundle")
+			}
+		}
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return errors.Wrapf(err, "decode response of %s", rawURL)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// tfCLIConfigFileEnv is the environment variable of the CLI config file terraform reads
+	tfCLIConfigFileEnv = "TF_CLI_CONFIG_FILE"
+	// cliConfigFileName is the name of the generated CLI config file in the project dir
+	cliConfigFileName = ".terraformrc"
+)
+
+// cliConfig is the terraform CLI config of the terraform run by cdktf for a config
+// https://developer.hashicorp.com/terraform/cli/config/config-file
+type cliConfig struct {
+	// Credentials are the registry tokens by hostname
+	Credentials map[string]string
+	// Mirror is the filesystem mirror directory the Providers are installed from
+	Mirror string
+	// Providers are the addresses of the providers installed from the Mirror,
+	// every other provider is installed from its registry.
+	// If empty, every provider is installed from the Mirror only.
+	Providers []string
+}
+
+// IsEmpty returns true if the CLI config has no settings, i.e., terraform can run without it
+func (c *cliConfig) IsEmpty() bool {
+	return len(c.Credentials) == 0 && c.Mirror == ""
+}
+
+// Write writes the CLI config file, the file is only readable by the current user
+// as it may contain tokens
+func (c *cliConfig) Write(path string) error {
+	hosts := make([]string, 0, len(c.Credentials))
+	for host := range c.Credentials {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	var sb strings.Builder
+	for _, host := range hosts {
+		fmt.Fprintf(&sb, "credentials %s {\n  token = %s\n}\n", hclQuote(host), hclQuote(c.Credentials[host]))
+	}
+	switch {
+	case c.Mirror != "" && len(c.Providers) > 0:
+		providers := quoteList(c.Providers)
+		fmt.Fprintf(&sb, "provider_installation {\n")
+		fmt.Fprintf(&sb, "  filesystem_mirror {\n    path    = %s\n    include = %s\n  }\n", hclQuote(c.Mirror), providers)
+		fmt.Fprintf(&sb, "  direct {\n    exclude = %s\n  }\n", providers)
+		fmt.Fprintf(&sb, "}\n")
+	case c.Mirror != "":
+		fmt.Fprintf(&sb, "provider_installation {\n")
+		fmt.Fprintf(&sb, "  filesystem_mirror {\n    path = %s\n  }\n", hclQuote(c.Mirror))
+		fmt.Fprintf(&sb, "}\n")
+	}
+	return os.WriteFile(path, []byte(sb.String()), 0600)
+}
+
+// quoteList returns the HCL list of the quoted strings
+func quoteList(s []string) string {
+	quoted := make([]string, len(s))
+	for i, v := range s {
+		quoted[i] = hclQuote(v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// hclQuote returns the HCL string literal of s, template sequences are escaped
+func hclQuote(s string) string {
+	s = strings.NewReplacer("${", "$${", "%{", "%%{").Replace(s)
+	return strconv.Quote(s)
+}
+
+// environWithCLIConfig returns environ using the CLI config file at path. The TF_TOKEN_*
+// variables are removed as their tokens are in the CLI config file already.
+func environWithCLIConfig(environ []string, path string) []string {
+	result := make([]string, 0, len(environ)+1)
+	for _, kv := range environ {
+		if strings.HasPrefix(kv, tfTokenEnvPrefix) || strings.HasPrefix(kv, tfCLIConfigFileEnv+"=") {
+			continue
+		}
+		result = append(result, kv)
+	}
+	return append(result, tfCLIConfigFileEnv+"="+path)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCLIConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config cliConfig
+		want   autogold.Value
+	}{
+		{
+			name: "credentials",
+			config: cliConfig{Credentials: map[string]string{
+				"registry.example.com": `to"ken\with${template}`,
+				"app.terraform.io":     "token",
+			}},
+			want: autogold.Expect(`credentials "app.terraform.io" {
+  token = "token"
+}
+credentials "registry.example.com" {
+  token = "to\"ken\\with$${template}"
+}
+`),
+		},
+		{
+			name:   "mirror only",
+			config: cliConfig{Mirror: "/tmp/mirror"},
+			want: autogold.Expect(`provider_installation {
+  filesystem_mirror {
+    path = "/tmp/mirror"
+  }
+}
+`),
+		},
+		{
+			name: "mirror with providers",
+			config: cliConfig{
+				Mirror:    "/tmp/mirror",
+				Providers: []string{"registry.terraform.io/hashicorp/google"},
+			},
+			want: autogold.Expect(`provider_installation {
+  filesystem_mirror {
+    path    = "/tmp/mirror"
+    include = ["registry.terraform.io/hashicorp/google"]
+  }
+  direct {
+    exclude = ["registry.terraform.io/hashicorp/google"]
+  }
+}
+`),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), cliConfigFileName)
+			require.NoError(t, tc.config.Write(path))
+
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			// the file may contain tokens
+			require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+			got, err := os.ReadFile(path)
+			require.NoError(t, err)
+			tc.want.Equal(t, string(got))
+		})
+	}
+}
+
+func TestHCLQuote(t *testing.T) {
+	got := make(map[string]string)
+	for _, s := range []string{`token`, `to"ken`, `to\ken`, "to\nken", `${var}`, `%{if}`, `$$`} {
+		got[s] = hclQuote(s)
+	}
+	autogold.Expect(map[string]string{
+		"$$":      `"$$"`,
+		"${var}":  `"$${var}"`,
+		"%{if}":   `"%%{if}"`,
+		"to\nken": `"to\nken"`,
+		`to"ken`:  `"to\"ken"`,
+		"to\\ken": `"to\\ken"`,
+		"token":   `"token"`,
+	}).Equal(t, got)
+}
+
+func TestEnvironWithCLIConfig(t *testing.T) {
+	got := environWithCLIConfig([]string{
+		"HOME=/home/user",
+		"TF_TOKEN_app_terraform_io=token",
+		"TF_CLI_CONFIG_FILE=/home/user/.terraformrc",
+	}, "/tmp/cli/.terraformrc")
+	autogold.Expect([]string{"HOME=/home/user", "TF_CLI_CONFIG_FILE=/tmp/cli/.terraformrc"}).Equal(t, got)
+}
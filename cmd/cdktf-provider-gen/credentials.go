@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/generator"
+)
+
+// tfTokenEnvPrefix is the prefix of the environment variables terraform reads registry tokens from
+// https://developer.hashicorp.com/terraform/cli/config/config-file#environment-variable-credentials
+const tfTokenEnvPrefix = "TF_TOKEN_"
+
+// loadCredentials returns the registry tokens by hostname of the credentials file and
+// the TF_TOKEN_* variables of environ, the latter take precedence.
+// If path is empty, the default terraform credentials file is read if it exists.
+func loadCredentials(path string, environ []string) (map[string]string, error) {
+	creds := make(map[string]string)
+	if path == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			if p := filepath.Join(home, ".terraform.d", "credentials.tfrc.json"); fileExists(p) {
+				path = p
+			}
+		}
+	}
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "read credentials file")
+		}
+		var f struct {
+			Credentials map[string]struct {
+				Token string `json:"token"`
+			} `json:"credentials"`
+		}
+		if err := json.Unmarshal(b, &f); err != nil {
+			return nil, errors.Wrapf(err, "parse credentials file %q", path)
+		}
+		for host, c := range f.Credentials {
+			if c.Token != "" {
+				creds[strings.ToLower(host)] = c.Token
+			}
+		}
+	}
+	for _, kv := range environ {
+		name, token, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, tfTokenEnvPrefix) || token == "" {
+			continue
+		}
+		creds[tokenEnvHost(strings.TrimPrefix(name, tfTokenEnvPrefix))] = token
+	}
+	return creds, nil
+}
+
+// tokenEnvHost decodes the hostname of a TF_TOKEN_* variable name, where a
+// double underscore is a hyphen and a single underscore is a dot,
+// e.g., TF_TOKEN_my__registry_example_com is my-registry.example.com.
+func tokenEnvHost(s string) string {
+	s = strings.ReplaceAll(s, "__", "-")
+	s = strings.ReplaceAll(s, "_", ".")
+	return strings.ToLower(s)
+}
+
+// configCredentials returns the registry tokens by hostname of the config,
+// which take precedence over the loaded credentials.
+func configCredentials(config *generator.Config, loaded map[string]string) map[string]string {
+	creds := make(map[string]string, len(loaded)+len(config.Credentials))
+	for host, token := range loaded {
+		creds[host] = token
+	}
+	for host, c := range config.Credentials {
+		creds[strings.ToLower(host)] = string(c.Token)
+	}
+	return creds
+}
+
+// secrets returns the tokens of creds, e.g., to redact them from logs
+func secrets(creds map[string]string) []string {
+	s := make([]string, 0, len(creds))
+	for _, token := range creds {
+		s = append(s, token)
+	}
+	return s
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
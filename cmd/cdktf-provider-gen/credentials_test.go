@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/generator"
+)
+
+func TestTokenEnvHost(t *testing.T) {
+	got := make(map[string]string)
+	for _, name := range []string{"app_terraform_io", "my__registry_example_com", "Registry_Example_COM"} {
+		got[name] = tokenEnvHost(name)
+	}
+	autogold.Expect(map[string]string{
+		"Registry_Example_COM":     "registry.example.com",
+		"app_terraform_io":         "app.terraform.io",
+		"my__registry_example_com": "my-registry.example.com",
+	}).Equal(t, got)
+}
+
+func TestLoadCredentials(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "credentials.tfrc.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+  "credentials": {
+    "App.Terraform.io": {"token": "file-token"},
+    "registry.example.com": {"token": "file-token"},
+    "empty.example.com": {"token": ""}
+  }
+}`), 0600))
+	environ := []string{
+		"HOME=/home/user",
+		"TF_TOKEN_registry_example_com=env-token",
+		"TF_TOKEN_my__registry_example_com=env-token",
+		"TF_TOKEN_unset_example_com=",
+	}
+
+	t.Run("env takes precedence over the file", func(t *testing.T) {
+		got, err := loadCredentials(path, environ)
+		require.NoError(t, err)
+		autogold.Expect(map[string]string{
+			"app.terraform.io":        "file-token",
+			"my-registry.example.com": "env-token",
+			"registry.example.com":    "env-token",
+		}).Equal(t, got)
+	})
+
+	t.Run("config takes precedence over the loaded credentials", func(t *testing.T) {
+		loaded, err := loadCredentials(path, environ)
+		require.NoError(t, err)
+		got := configCredentials(&generator.Config{
+			Credentials: map[string]generator.HostCredentials{
+				"Registry.Example.com": {Token: "config-token"},
+			},
+		}, loaded)
+		autogold.Expect(map[string]string{
+			"app.terraform.io":        "file-token",
+			"my-registry.example.com": "env-token",
+			"registry.example.com":    "config-token",
+		}).Equal(t, got)
+	})
+
+	t.Run("default credentials file", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+		got, err := loadCredentials("", nil)
+		require.NoError(t, err)
+		require.Empty(t, got)
+
+		require.NoError(t, os.MkdirAll(filepath.Join(home, ".terraform.d"), 0700))
+		require.NoError(t, os.WriteFile(filepath.Join(home, ".terraform.d", "credentials.tfrc.json"),
+			[]byte(`{"credentials": {"app.terraform.io": {"token": "default-token"}}}`), 0600))
+		got, err = loadCredentials("", nil)
+		require.NoError(t, err)
+		autogold.Expect(map[string]string{"app.terraform.io": "default-token"}).Equal(t, got)
+	})
+
+	t.Run("invalid credentials file", func(t *testing.T) {
+		invalid := filepath.Join(dir, "invalid.json")
+		require.NoError(t, os.WriteFile(invalid, []byte(`credentials`), 0600))
+		_, err := loadCredentials(invalid, nil)
+		require.Error(t, err)
+		require.NotContains(t, err.Error(), "file-token")
+	})
+}
+
+func TestSecrets(t *testing.T) {
+	got := secrets(map[string]string{"app.terraform.io": "token"})
+	autogold.Expect([]string{"token"}).Equal(t, got)
+}
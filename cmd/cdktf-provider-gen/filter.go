@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/cdktf"
+)
+
+// tfResourceTypePattern matches the terraform type of a generated resource or data source class
+var tfResourceTypePattern = regexp.MustCompile(`tfResourceType = "([^"]+)"`)
+
+// providerIndexFiles are the files of the generated provider code that export every
+// resource and data source directory, cdktf writes one line per directory
+var providerIndexFiles = []string{"index.ts", "lazy-index.ts"}
+
+// filterProviderResources removes the directories of the resources and data sources the
+// filter does not keep from the provider code generated into dir, and their exports.
+// Every directory holds a single resource or data source with its supporting types,
+// the provider construct is always kept. It returns the number of kept types.
+func filterProviderResources(dir string, filter cdktf.ResourceFilter) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, errors.Wrap(err, "read generated provider")
+	}
+	var kept int
+	var removed []string
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == "provider" {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, entry.Name(), "index.ts"))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return 0, errors.Wrapf(err, "read %s", entry.Name())
+		}
+		match := tfResourceTypePattern.FindSubmatch(b)
+		if match == nil {
+			continue
+		}
+		if filter.Match(string(match[1])) {
+			kept++
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return 0, errors.Wrapf(err, "remove %s", entry.Name())
+		}
+		removed = append(removed, entry.Name())
+	}
+	if kept == 0 {
+		return 0, errors.New("no resources or data sources match include and exclude")
+	}
+
+	exports := make(map[string]bool, len(removed))
+	for _, name := range removed {
+		exports["'./"+name+"'"] = true
+	}
+	for _, name := range providerIndexFiles {
+		path := filepath.Join(dir, name)
+		b, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return 0, errors.Wrapf(err, "read %s", name)
+		}
+		lines := strings.SplitAfter(string(b), "\n")
+		filtered := lines[:0]
+		for _, line := range lines {
+			if !exports[exportedDir(line)] {
+				filtered = append(filtered, line)
+			}
+		}
+		if err := os.WriteFile(path, []byte(strings.Join(filtered, "")), 0644); err != nil {
+			return 0, errors.Wrapf(err, "write %s", name)
+		}
+	}
+	return kept, nil
+}
+
+// exportedDirPattern matches the quoted directory of an export of a provider index file, e.g.,
+// export * as computeInstance from './compute-instance'; or require('./compute-instance')
+var exportedDirPattern = regexp.MustCompile(`'\./[^'/]+'`)
+
+// exportedDir returns the quoted directory exported by the line of a provider index file, if any
+func exportedDir(line string) string {
+	return exportedDirPattern.FindString(line)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/cdktf"
+)
+
+// writeGeneratedProvider writes the layout of the google provider generated by cdktf
+// with a resource and two data sources into dir, lazy adds the lazy-index.ts of large providers
+func writeGeneratedProvider(t *testing.T, dir string, lazy bool) {
+	t.Helper()
+	files := map[string]string{
+		"provider/index.ts":                   `export class GoogleProvider extends cdktf.TerraformProvider { public static readonly tfResourceType = "google"; }`,
+		"compute-instance/index.ts":           `export class ComputeInstance extends cdktf.TerraformResource { public static readonly tfResourceType = "google_compute_instance"; }`,
+		"data-google-compute-image/index.ts":  `export class DataGoogleComputeImage extends cdktf.TerraformDataSource { public static readonly tfResourceType = "google_compute_image"; }`,
+		"data-google-storage-bucket/index.ts": `export class DataGoogleStorageBucket extends cdktf.TerraformDataSource { public static readonly tfResourceType = "google_storage_bucket"; }`,
+		"index.ts": `// generated by cdktf get
+export * as computeInstance from './compute-instance';
+export * as dataGoogleComputeImage from './data-google-compute-image';
+export * as dataGoogleStorageBucket from './data-google-storage-bucket';
+export * as provider from './provider';
+
+`,
+	}
+	if lazy {
+		files["lazy-index.ts"] = `// generated by cdktf get
+Object.defineProperty(exports, 'computeInstance', { get: function () { return require('./compute-instance'); } });
+Object.defineProperty(exports, 'dataGoogleComputeImage', { get: function () { return require('./data-google-compute-image'); } });
+Object.defineProperty(exports, 'dataGoogleStorageBucket', { get: function () { return require('./data-google-storage-bucket'); } });
+Object.defineProperty(exports, 'provider', { get: function () { return require('./provider'); } });
+`
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestFilterProviderResources(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter cdktf.ResourceFilter
+		lazy   bool
+		want   autogold.Value
+	}{
+		{
+			name:   "include",
+			filter: cdktf.ResourceFilter{Include: []string{"google_compute_*"}},
+			want: autogold.Expect(map[string]string{
+				"dirs": "compute-instance data-google-compute-image provider",
+				"index.ts": `// generated by cdktf get
+export * as computeInstance from './compute-instance';
+export * as dataGoogleComputeImage from './data-google-compute-image';
+export * as provider from './provider';
+
+`,
+				"kept": "2",
+			}),
+		},
+		{
+			name:   "exclude",
+			filter: cdktf.ResourceFilter{Exclude: []string{"google_compute_*"}},
+			want: autogold.Expect(map[string]string{
+				"dirs": "data-google-storage-bucket provider",
+				"index.ts": `// generated by cdktf get
+export * as dataGoogleStorageBucket from './data-google-storage-bucket';
+export * as provider from './provider';
+
+`,
+				"kept": "1",
+			}),
+		},
+		{
+			name:   "include and exclude with lazy index",
+			filter: cdktf.ResourceFilter{Include: []string{"google_compute_*", "google_storage_bucket"}, Exclude: []string{"google_compute_image"}},
+			lazy:   true,
+			want: autogold.Expect(map[string]string{
+				"dirs": "compute-instance data-google-storage-bucket provider",
+				"index.ts": `// generated by cdktf get
+export * as computeInstance from './compute-instance';
+export * as dataGoogleStorageBucket from './data-google-storage-bucket';
+export * as provider from './provider';
+
+`,
+				"kept": "2",
+				"lazy-index.ts": `// generated by cdktf get
+Object.defineProperty(exports, 'computeInstance', { get: function () { return require('./compute-instance'); } });
+Object.defineProperty(exports, 'dataGoogleStorageBucket', { get: function () { return require('./data-google-storage-bucket'); } });
+Object.defineProperty(exports, 'provider', { get: function () { return require('./provider'); } });
+`,
+			}),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeGeneratedProvider(t, dir, tc.lazy)
+
+			kept, err := filterProviderResources(dir, tc.filter)
+			require.NoError(t, err)
+
+			got := map[string]string{"kept": strconv.Itoa(kept)}
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			var dirs []string
+			for _, entry := range entries {
+				if entry.IsDir() {
+					dirs = append(dirs, entry.Name())
+				}
+			}
+			got["dirs"] = strings.Join(dirs, " ")
+			for _, name := range providerIndexFiles {
+				if b, err := os.ReadFile(filepath.Join(dir, name)); err == nil {
+					got[name] = string(b)
+				}
+			}
+			tc.want.Equal(t, got)
+		})
+	}
+
+	t.Run("no match", func(t *testing.T) {
+		dir := t.TempDir()
+		writeGeneratedProvider(t, dir, true)
+
+		_, err := filterProviderResources(dir, cdktf.ResourceFilter{Include: []string{"google_sql_*"}})
+		require.Error(t, err)
+		autogold.Expect("no resources or data sources match include and exclude").Equal(t, err.Error())
+	})
+
+	t.Run("provider is not filtered", func(t *testing.T) {
+		dir := t.TempDir()
+		writeGeneratedProvider(t, dir, false)
+
+		// the provider has a tfResourceType too, but is never matched against the filter
+		_, err := filterProviderResources(dir, cdktf.ResourceFilter{Exclude: []string{"google"}})
+		require.NoError(t, err)
+		assert.DirExists(t, filepath.Join(dir, "provider"))
+	})
+}
@@ -2,20 +2,91 @@ package main
 
 import (
 	"github.com/urfave/cli/v2"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/generator"
 )
 
 var (
-	configFlag = &cli.StringFlag{
-		Name:     "config",
-		Aliases:  []string{"c"},
-		Required: true,
+	configFlag = &cli.StringSliceFlag{
+		Name:    "config",
+		Aliases: []string{"c"},
+		Usage:   "Path to a config or batch config file, can be repeated",
+	}
+	defaultsFlag = &cli.StringFlag{
+		Name:    "defaults",
+		Usage:   "Path to a defaults file merged into every config, defaults to the closest " + generator.DefaultsFileName + " in the directory of the config file or its parents",
+		EnvVars: []string{"CDKTF_PROVIDER_GEN_DEFAULTS"},
+	}
+	setFlag = &cli.StringSliceFlag{
+		Name:  "set",
+		Usage: "Set a value on every config by its path, e.g., provider.version=4.69.1, can be repeated",
 	}
 	cdktfVersionFlag = &cli.StringFlag{
 		Name:    "cdktf-version",
-		Usage:   "The target cdktf version to use",
+		Usage:   "The cdktf version to use for configs without cdktfVersion",
 		Value:   "0.16.3",
 		EnvVars: []string{"CDKTF_VERSION"},
 	}
+	toolchainFlag = &cli.StringFlag{
+		Name:    "toolchain",
+		Usage:   "The binary cdktf fetches schemas with for configs without toolchain, terraform or opentofu",
+		Value:   generator.ToolchainTerraform,
+		EnvVars: []string{"CDKTF_PROVIDER_GEN_TOOLCHAIN"},
+	}
+	terraformVersionFlag = &cli.StringFlag{
+		Name:    "terraform-version",
+		Usage:   "The terraform version to use for configs without terraformVersion",
+		Value:   "1.5.5",
+		EnvVars: []string{"TERRAFORM_VERSION"},
+	}
+	openTofuVersionFlag = &cli.StringFlag{
+		Name:    "opentofu-version",
+		Usage:   "The OpenTofu version to use for opentofu configs without terraformVersion",
+		Value:   "1.8.3",
+		EnvVars: []string{"OPENTOFU_VERSION"},
+	}
+	terraformBinaryFlag = &cli.StringFlag{
+		Name:    "terraform-binary",
+		Usage:   "Path to the terraform or tofu binary to use instead of installing it, its version must match the terraform version of every config",
+		EnvVars: []string{"CDKTF_PROVIDER_GEN_TERRAFORM_BINARY"},
+	}
+	terraformFromPathFlag = &cli.BoolFlag{
+		Name:  "terraform-from-path",
+		Usage: "Use the terraform or tofu binary on PATH if it is the requested version instead of installing it",
+	}
+	toolchainCacheDirFlag = &cli.StringFlag{
+		Name:    "toolchain-cache-dir",
+		Usage:   "Directory terraform and OpenTofu are installed in by version and reused across runs, defaults to cdktf-provider-gen/toolchains in the user cache dir",
+		EnvVars: []string{"CDKTF_PROVIDER_GEN_TOOLCHAIN_CACHE_DIR"},
+	}
+	schemaFlag = &cli.BoolFlag{
+		Name:  "schema",
+		Usage: "Print the JSON Schema of the config file format",
+	}
+	concurrencyFlag = &cli.IntFlag{
+		Name:  "concurrency",
+		Usage: "Maximum number of configs to generate at the same time",
+		Value: 1,
+	}
+	registryURLFlag = &cli.StringFlag{
+		Name:    "registry-url",
+		Usage:   "Override the Terraform registry endpoint used to resolve version constraints, e.g., a local registry",
+		EnvVars: []string{"CDKTF_PROVIDER_GEN_REGISTRY_URL"},
+	}
+	credentialsFileFlag = &cli.StringFlag{
+		Name:    "credentials-file",
+		Usage:   "Path to a terraform credentials.tfrc.json file with registry tokens, defaults to ~/.terraform.d/credentials.tfrc.json if it exists",
+		EnvVars: []string{"CDKTF_PROVIDER_GEN_CREDENTIALS_FILE"},
+	}
+	bundleFlag = &cli.StringFlag{
+		Name:    "bundle",
+		Usage:   "Directory of the bundle to generate offline from with -offline, or to fill with the prefetch command",
+		EnvVars: []string{"CDKTF_PROVIDER_GEN_BUNDLE"},
+	}
+	offlineFlag = &cli.BoolFlag{
+		Name:  "offline",
+		Usage: "Generate without network access, everything is read from the -bundle dir",
+	}
 	keepFlag = &cli.BoolFlag{
 		Name:  "keep",
 		Usage: "Retain the intermediate assets, useful for debugging codegen error",
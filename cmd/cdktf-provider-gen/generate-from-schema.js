@@ -0,0 +1,49 @@
+// Generates the bindings of a provider from its schema exported with
+// `terraform providers schema -json`, the same way `cdktf get` does but
+// without running terraform.
+//
+// Usage: node generate-from-schema.js <schema file> <provider address> <provider version> <outdir>
+const fs = require("fs");
+const path = require("path");
+
+function fail(message) {
+  console.error(`generate-from-schema: ${message}`);
+  process.exit(1);
+}
+
+// TerraformProviderGenerator is not part of the documented API of @cdktf/provider-generator,
+// and codemaker is pinned to the version it depends on in package.json
+function load(pkg, name) {
+  let exported;
+  try {
+    exported = require(pkg)[name];
+  } catch (err) {
+    fail(`cannot load ${pkg}: ${err.message}`);
+  }
+  if (typeof exported !== "function") {
+    fail(`${pkg} has no ${name} export, generating from a schema file is not supported by this cdktf version`);
+  }
+  return exported;
+}
+
+const CodeMaker = load("codemaker", "CodeMaker");
+const TerraformProviderGenerator = load("@cdktf/provider-generator", "TerraformProviderGenerator");
+
+const [schemaFile, address, version, outdir] = process.argv.slice(2);
+const schema = JSON.parse(fs.readFileSync(schemaFile, "utf8"));
+
+const code = new CodeMaker();
+const generator = new TerraformProviderGenerator(code, schema);
+if (typeof generator.generate !== "function") {
+  fail("TerraformProviderGenerator of @cdktf/provider-generator has no generate method, generating from a schema file is not supported by this cdktf version");
+}
+generator.generate(address);
+code
+  .save(outdir)
+  .then(() => {
+    fs.writeFileSync(path.join(outdir, "versions.json"), JSON.stringify({ [address]: version }, null, 2));
+  })
+  .catch((err) => {
+    console.error(err);
+    process.exit(1);
+  });
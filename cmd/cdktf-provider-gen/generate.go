@@ -0,0 +1,553 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	hcversion "github.com/hashicorp/go-version"
+	cp "github.com/otiai10/copy"
+	"github.com/sourcegraph/log"
+	"github.com/sourcegraph/run"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+	"golang.org/x/mod/semver"
+
+	"github.com/sourcegraph/cdktf-provider-gen/internal/observability"
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/cdktf"
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/generator"
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/registry"
+)
+
+// generateOptions are the settings shared by every config generated in a run
+type generateOptions struct {
+	// Keep retains the intermediate assets
+	Keep bool
+	// WorkDir is the directory config outputs are relative to
+	WorkDir string
+	// Environ is the environment of every command run by the pipeline,
+	// e.g., with the terraform binary of the toolchain in PATH
+	Environ []string
+	// Registry is used to resolve version constraints to exact versions
+	Registry *registry.Client
+	// Credentials are the registry tokens by hostname of the credentials file and
+	// TF_TOKEN_* variables, the credentials of a config take precedence
+	Credentials map[string]string
+	// Bundle is the bundle everything fetched is read from offline or added to by prefetch, if any
+	Bundle *bundle
+	// Outputs are the output dirs claimed by the configs of the run, if any
+	Outputs *outputClaims
+}
+
+// outputClaims detects configs writing to the same output directory, which would
+// overwrite each other. It is safe for concurrent use.
+type outputClaims struct {
+	// WorkDir is the directory config outputs are relative to
+	WorkDir string
+
+	mu sync.Mutex
+	// configs are the configs by the output dirs they claimed
+	configs map[string]*generator.Config
+}
+
+// claim claims the output dirs of the config, it returns an error if another config
+// claimed any of them already. Templates of the config must be rendered first. o may be nil.
+func (o *outputClaims) claim(config *generator.Config) error {
+	if o == nil {
+		return nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.configs == nil {
+		o.configs = make(map[string]*generator.Config)
+	}
+	dirs := config.OutputDirs()
+	for _, dir := range dirs {
+		if other, ok := o.configs[filepath.Join(o.WorkDir, dir)]; ok && other != config {
+			return errors.Newf("output dir %q of config %q is also the output dir of config %q", dir, config.Name, other.Name)
+		}
+	}
+	for _, dir := range dirs {
+		o.configs[filepath.Join(o.WorkDir, dir)] = config
+	}
+	return nil
+}
+
+// generateAll generates every config with at most concurrency configs at the same time.
+// A failing config does not stop the others from being generated.
+func generateAll(ctx context.Context, logger log.Logger, configs []*generator.Config, opts generateOptions, concurrency int) generateSummary {
+	summary := make(generateSummary, len(configs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				config := configs[i]
+				start := time.Now()
+				err := generate(ctx, logger.Scoped(config.Name), config, opts)
+				if err != nil {
+					logger.Error("failed to generate", log.String("name", config.Name), log.Error(err))
+				}
+				summary[i] = generateResult{
+					Name:     config.Name,
+					Duration: time.Since(start),
+					Err:      err,
+				}
+			}
+		}()
+	}
+	for i := range configs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return summary
+}
+
+// environWithPath returns the current environment with dir prepended to PATH
+func environWithPath(dir string) []string {
+	return append(os.Environ(), "PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// generate runs the code generation pipeline for a single config
+func generate(ctx context.Context, logger log.Logger, config *generator.Config, opts generateOptions) error {
+	logger = logger.With(log.String("name", config.Name))
+	for _, module := range config.Module {
+		if opts.Bundle != nil && module.LocalPath == "" {
+			// terraform has no mirror of modules, they are always downloaded from their source
+			return errors.New("only local modules can be generated offline, other modules are always downloaded from their source")
+		}
+	}
+
+	creds := configCredentials(config, opts.Credentials)
+	client := *opts.Registry
+	client.Credentials = creds
+	metadata, err := resolveVersions(ctx, logger, config, &client, opts.Bundle)
+	if err != nil {
+		return errors.Wrap(err, "resolve versions")
+	}
+	metadata.CdktfVersion = config.CdktfVersion
+	metadata.Toolchain = config.Toolchain
+	metadata.TerraformVersion = config.TerraformVersion
+	// the outputs of configs with templates are only known once versions are resolved
+	if err := config.RenderTemplates(metadata.Version()); err != nil {
+		return errors.Wrap(err, "render templates")
+	}
+	if err := opts.Outputs.claim(config); err != nil {
+		return err
+	}
+	if b, err := json.Marshal(config); err == nil {
+		// the config after merging, interpolation and version resolution
+		logger.Debug("resolved config", log.String("config", string(b)))
+	}
+	if config.Provider != nil {
+		logger = logger.With(
+			log.String("provider.name", config.Provider.Name),
+			log.String("provider.version", config.Provider.Version),
+		)
+	}
+	if len(config.Module) > 0 {
+		sources := make([]string, len(config.Module))
+		versions := make([]string, len(config.Module))
+		for i, module := range config.Module {
+			sources[i], versions[i] = module.Source, module.Version
+		}
+		logger = logger.With(
+			log.Strings("module.source", sources),
+			log.Strings("module.version", versions),
+		)
+	}
+
+	m := cdktf.Manifest{
+		Language:         "typescript",
+		App:              "echo noop",
+		SendCrashReports: false,
+		ProjectID:        "noop",
+	}
+	var providerAddr cdktf.ProviderAddress
+	if config.Provider != nil {
+		providerAddr, err = cdktf.ParseProviderAddressWithHost(config.Provider.Source, config.RegistryHost())
+		if err != nil {
+			return err
+		}
+		// this is a special handling for provider name with hyphens
+		providerName, ok := Last(strings.Split(config.Provider.Source, "/"))
+		if !ok {
+			return errors.Newf("provider name not found: %q", config.Provider.Source)
+		}
+		config.Provider.Name = providerName
+		// local providers are installed through the CLI config, cdktf only needs the address
+		m.TerraformProviders = []cdktf.Source{{
+			Name:    config.Provider.Name,
+			Source:  config.Provider.Source,
+			Version: config.Provider.Version,
+		}}
+	}
+	for _, module := range config.Module {
+		source := module.Source
+		if module.LocalPath != "" {
+			// cdktf fetches the module schema from another directory, local paths must be absolute
+			if source, err = filepath.Abs(module.LocalPath); err != nil {
+				return errors.Wrap(err, "resolve local module path")
+			}
+		}
+		m.TerraformModules = append(m.TerraformModules, cdktf.Source{
+			Name:    module.Name,
+			Source:  source,
+			Version: module.Version,
+		})
+	}
+	var cdktfJSON bytes.Buffer
+	enc := json.NewEncoder(&cdktfJSON)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(m); err != nil {
+		return errors.Wrap(err, "marshal cdktf.json")
+	}
+
+	deps, err := fetchCdktfDependencies(ctx, opts.Bundle, config.CdktfVersion)
+	if err != nil {
+		return errors.Wrap(err, "fetch cdktf dependencies")
+	}
+	deps.Cdktf = config.CdktfVersion
+	if config.Provider != nil && config.Provider.SchemaFile != "" {
+		// the schema is generated with the internals of @cdktf/provider-generator,
+		// codemaker must be the exact version it is built with
+		if deps.Codemaker, err = fetchCodemakerVersion(ctx, opts.Bundle, config.CdktfVersion); err != nil {
+			return errors.Wrap(err, "fetch codemaker version")
+		}
+	}
+
+	version := npmPackageVersion(metadata)
+	targets, err := languageTargets(config, version, opts.Bundle)
+	if err != nil {
+		return err
+	}
+	jsiiTargets := make(map[string]any, len(targets))
+	for _, t := range targets {
+		if t.JsiiConfig != nil {
+			jsiiTargets[t.Pacmak] = t.JsiiConfig
+		}
+	}
+	jsiiTargetsJSON, err := json.Marshal(jsiiTargets)
+	if err != nil {
+		return errors.Wrap(err, "marshal jsii targets")
+	}
+
+	data := projectTemplateData{
+		Config:         *config,
+		PackageName:    npmPackageName(config),
+		PackageVersion: jsiiProjectVersion,
+		Targets:        targets,
+		JsiiTargets:    string(jsiiTargetsJSON),
+		Deps:           *deps,
+	}
+	if config.Provider != nil {
+		data.ProviderAddress = providerAddr.String()
+	}
+	var packageJSON bytes.Buffer
+	if err := packageJSONTemplate.Execute(&packageJSON, data); err != nil {
+		return errors.Wrap(err, "render package.json")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "cdktfprovidergen")
+	if err != nil {
+		return errors.Wrap(err, "create temp dir")
+	}
+	if !opts.Keep {
+		defer os.RemoveAll(tmpDir)
+	}
+	logger = logger.With(log.String("tmpDir", tmpDir))
+
+	logger.Debug("write package.json")
+	if err := os.WriteFile(filepath.Join(tmpDir, "package.json"), packageJSON.Bytes(), 0644); err != nil {
+		return errors.Wrap(err, "write package.json")
+	}
+	logger.Debug("write cdktf.json")
+	if err := os.WriteFile(filepath.Join(tmpDir, "cdktf.json"), cdktfJSON.Bytes(), 0644); err != nil {
+		return errors.Wrap(err, "write cdktf.json")
+	}
+	if config.Provider != nil && config.Provider.SchemaFile != "" {
+		// the bindings are generated from the schema file instead of `cdktf get`, without terraform
+		logger.Debug("write provider schema", log.String("schemaFile", config.Provider.SchemaFile))
+		if err := writeProviderSchema(config.Provider.SchemaFile, providerAddr, tmpDir); err != nil {
+			return err
+		}
+	}
+
+	// terraform reads the registry tokens and the local provider mirror from
+	// the CLI config when cdktf fetches providers and modules
+	cli := cliConfig{Credentials: creds}
+	switch {
+	case config.Provider != nil && config.Provider.IsLocal():
+		if cli.Mirror, err = localProviderMirror(config.Provider, providerAddr, tmpDir); err != nil {
+			return err
+		}
+		cli.Providers = []string{providerAddr.String()}
+		logger.Debug("installing local provider", log.String("mirror", cli.Mirror))
+	case opts.Bundle.offline():
+		// every provider is installed from the bundle only
+		cli.Mirror = opts.Bundle.providersDir()
+	}
+	environ := opts.Environ
+	if opts.Bundle != nil {
+		environ = opts.Bundle.environ(environ)
+	}
+	if !cli.IsEmpty() {
+		logger.Debug("write terraform CLI config")
+		// the CLI config holds the registry tokens, so it is written outside of
+		// tmpDir and always removed, even if the intermediate assets are kept
+		cliConfigDir, err := os.MkdirTemp("", "cdktfprovidergen-cli")
+		if err != nil {
+			return errors.Wrap(err, "create terraform CLI config dir")
+		}
+		defer os.RemoveAll(cliConfigDir)
+		cliConfigPath := filepath.Join(cliConfigDir, cliConfigFileName)
+		if err := cli.Write(cliConfigPath); err != nil {
+			return errors.Wrap(err, "write terraform CLI config")
+		}
+		if environ == nil {
+			environ = os.Environ()
+		}
+		environ = environWithCLIConfig(environ, cliConfigPath)
+	}
+
+	logger.Debug("compiling cdktf provider code")
+	cmdCtx := observability.LogCommands(ctx, logger, secrets(creds)...)
+	runAll := func(cmds ...string) error {
+		for _, cmd := range cmds {
+			if err := run.Cmd(cmdCtx, cmd).Dir(tmpDir).Environ(environ).Run().Wait(); err != nil {
+				return errors.Wrapf(err, "run: %q", cmd)
+			}
+		}
+		return nil
+	}
+	if err := runAll("npm install --no-save", "npm run fetch"); err != nil {
+		return err
+	}
+	if config.Provider != nil && !config.Provider.Filter().IsEmpty() {
+		kept, err := filterProviderResources(filepath.Join(tmpDir, "src"), config.Provider.Filter())
+		if err != nil {
+			return errors.Wrap(err, "filter provider resources")
+		}
+		logger.Debug("filtered provider resources", log.Int("kept", kept))
+	}
+	for i, module := range config.Module {
+		if module.LocalPath == "" {
+			continue
+		}
+		// the generated code must use the source of the config rather than the absolute path of this machine
+		if err := replaceModuleSource(filepath.Join(tmpDir, "src", module.Name+".ts"), m.TerraformModules[i].Source, module.Source); err != nil {
+			return errors.Wrapf(err, "replace source of local module %q", module.Name)
+		}
+	}
+	if len(config.Module) > 0 {
+		if err := writeModuleIndex(filepath.Join(tmpDir, "src", "index.ts"), config.Module); err != nil {
+			return err
+		}
+	}
+	cmds := []string{
+		"npm run compile",
+		"rm -rf ./src", // remove the source code dir `./src`, we only need `./lib`, shave off a few extra bytes
+	}
+	for _, t := range targets {
+		cmds = append(cmds, "npm run pkg:"+t.Pacmak)
+	}
+	if err := runAll(cmds...); err != nil {
+		return err
+	}
+
+	for _, t := range targets {
+		if err := copyTarget(ctx, logger, t, tmpDir, filepath.Join(opts.WorkDir, config.Output, t.OutputName), metadata); err != nil {
+			return errors.Wrapf(err, "package %s target", t.Pacmak)
+		}
+	}
+	return nil
+}
+
+// npmPackageName returns the name of the npm package built from the config
+func npmPackageName(config *generator.Config) string {
+	if t := config.Target.TypeScript(); t != nil {
+		return t.NpmName()
+	}
+	return "@cdktf/provider-" + config.Name
+}
+
+// jsiiProjectVersion is the version of the jsii project every target is packaged from.
+// Only the npm package is versioned with the provider or module, the version of the
+// jsii project would otherwise add a major version suffix to the Go module path.
+const jsiiProjectVersion = "0.0.1"
+
+// npmPackageVersion returns the version of the npm package, i.e., the version of the
+// provider or modules it is generated from if it is a valid semver version.
+// Multiple modules only have a version if all of them have the same version.
+func npmPackageVersion(metadata *generator.Metadata) string {
+	if v := metadata.Version(); v != "" && semver.IsValid("v"+v) {
+		return v
+	}
+	return jsiiProjectVersion
+}
+
+// copyTarget post-processes the package of a language target and copies it to the output dir
+func copyTarget(ctx context.Context, logger log.Logger, t languageTarget, projectDir, outputDir string, metadata *generator.Metadata) error {
+	srcDir := filepath.Join(projectDir, t.DistDir)
+	logger = logger.With(log.String("target", t.Pacmak), log.String("srcDir", srcDir))
+	if t.PostProcess != nil {
+		logger.Debug("post-processing package")
+		if err := t.PostProcess(ctx, srcDir); err != nil {
+			return err
+		}
+	}
+
+	logger = logger.With(log.String("outputDir", outputDir))
+	logger.Debug("ensuring output dir is clean")
+	if _, err := os.Stat(outputDir); err == nil {
+		if err := os.RemoveAll(outputDir); err != nil {
+			return errors.Wrapf(err, "clean output dir %q", outputDir)
+		}
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return errors.Wrap(err, "create output dir")
+	}
+	logger.Debug("copying to output dir")
+	if err := cp.Copy(srcDir, outputDir, cp.Options{Skip: skipExcluded(t.Include)}); err != nil {
+		return errors.Wrap(err, "copy cdktf.out")
+	}
+	logger.Debug("write metadata")
+	if err := writeMetadata(filepath.Join(outputDir, generator.MetadataFileName), metadata); err != nil {
+		return errors.Wrap(err, "write metadata")
+	}
+
+	return nil
+}
+
+// resolveVersions resolves the version constraints of registry providers and modules
+// to the newest matching exact version, and updates the config with it.
+// Providers installed from a mirror are resolved against the versions in the mirror,
+// as are the providers of an offline bundle.
+func resolveVersions(ctx context.Context, logger log.Logger, config *generator.Config, client *registry.Client, b *bundle) (*generator.Metadata, error) {
+	metadata := &generator.Metadata{Name: config.Name}
+	if config.Provider != nil {
+		metadata.Provider = &generator.SourceMetadata{
+			Source:  config.Provider.Source,
+			Version: config.Provider.Version,
+			Include: config.Provider.Include,
+			Exclude: config.Provider.Exclude,
+		}
+		if !registry.IsExactVersion(config.Provider.Version) {
+			addr, err := cdktf.ParseProviderAddressWithHost(config.Provider.Source, config.RegistryHost())
+			if err != nil {
+				return nil, err
+			}
+			// a provider binary always has an exact version, a mirror has its own versions
+			var versions []*hcversion.Version
+			switch {
+			case config.Provider.Mirror != "":
+				versions, err = mirrorVersions(config.Provider.Mirror, addr)
+			case b.offline():
+				versions, err = mirrorVersions(b.providersDir(), addr)
+			default:
+				versions, err = client.ProviderVersions(ctx, addr)
+			}
+			if err != nil {
+				return nil, err
+			}
+			v, err := registry.Resolve(versions, config.Provider.Version)
+			if err != nil {
+				return nil, errors.Wrapf(err, "resolve version of provider %q", config.Provider.Source)
+			}
+			logger.Info("resolved provider version",
+				log.String("provider.source", config.Provider.Source),
+				log.String("constraint", config.Provider.Version),
+				log.String("version", v.String()))
+			metadata.Provider.Constraint = config.Provider.Version
+			metadata.Provider.Version = v.String()
+			config.Provider.Version = v.String()
+		}
+	}
+	for _, module := range config.Module {
+		moduleMetadata := &generator.SourceMetadata{
+			Source:  module.Source,
+			Version: module.Version,
+		}
+		metadata.Module = append(metadata.Module, moduleMetadata)
+		// only registry modules have versions, other module sources are pinned by their source
+		typ, err := cdktf.ParseModuleSourceType(module.Source)
+		if err != nil {
+			return nil, err
+		}
+		if typ == cdktf.ModuleSourceGit {
+			moduleMetadata.Ref = cdktf.ModuleSourceRef(module.Source)
+		}
+		if typ == cdktf.ModuleSourceRegistry && !registry.IsExactVersion(module.Version) {
+			addr, err := cdktf.ParseModuleAddressWithHost(module.Source, config.RegistryHost())
+			if err != nil {
+				return nil, err
+			}
+			versions, err := client.ModuleVersions(ctx, addr)
+			if err != nil {
+				return nil, err
+			}
+			v, err := registry.Resolve(versions, module.Version)
+			if err != nil {
+				return nil, errors.Wrapf(err, "resolve version of module %q", module.Source)
+			}
+			logger.Info("resolved module version",
+				log.String("module.source", module.Source),
+				log.String("constraint", module.Version),
+				log.String("version", v.String()))
+			moduleMetadata.Constraint = module.Version
+			moduleMetadata.Version = v.String()
+			module.Version = v.String()
+		}
+	}
+	return metadata, nil
+}
+
+func writeMetadata(path string, metadata *generator.Metadata) error {
+	b, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal metadata")
+	}
+	return os.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// generateResult is the outcome of generating a single config
+type generateResult struct {
+	Name     string
+	Duration time.Duration
+	Err      error
+}
+
+// generateSummary is the outcome of every config generated in a run
+type generateSummary []generateResult
+
+// Failed returns the number of configs that failed to generate
+func (s generateSummary) Failed() int {
+	var failed int
+	for _, r := range s {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+func (s generateSummary) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "generated %d of %d configs\n", len(s)-s.Failed(), len(s))
+	for _, r := range s {
+		if r.Err != nil {
+			fmt.Fprintf(&sb, "  FAIL  %s (%s): %s\n", r.Name, r.Duration.Round(time.Second), r.Err)
+			continue
+		}
+		fmt.Fprintf(&sb, "  OK    %s (%s)\n", r.Name, r.Duration.Round(time.Second))
+	}
+	return sb.String()
+}
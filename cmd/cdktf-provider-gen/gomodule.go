@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/generator"
+)
+
+// goMajorVersion returns the major version of the module path suffix of the go target, i.e.,
+// its explicit major version, or the major version of the package version if it is derived.
+// It is 0 if the module path has no suffix.
+func goMajorVersion(t *generator.GoTarget, version string) int {
+	if t.MajorVersion > 0 || !t.MajorVersionSuffix {
+		return t.MajorVersion
+	}
+	major, _ := strconv.Atoi(strings.TrimPrefix(semver.Major("v"+version), "v"))
+	return major
+}
+
+// goModulePath returns the module path of the go target, with the /v<major>
+// suffix Go requires for major versions 2 and above
+func goModulePath(t *generator.GoTarget, version string) string {
+	path := t.ModuleName + "/" + t.PackageName
+	if major := goMajorVersion(t, version); major >= 2 {
+		path += fmt.Sprintf("/v%d", major)
+	}
+	return path
+}
+
+// goModuleVersion returns the module version of the go target for the package version,
+// i.e., the package version with the major version of the module and the version suffix.
+// Without a major version, the package version is not a valid version of the module
+// path, and the version is v0.0.0.
+func goModuleVersion(t *generator.GoTarget, version string) string {
+	if t.MajorVersion == 0 && !t.MajorVersionSuffix {
+		return "v0.0.0"
+	}
+	_, rest, _ := strings.Cut(version, ".")
+	return fmt.Sprintf("v%d.%s%s", goMajorVersion(t, version), rest, t.VersionSuffix)
+}
+
+// setGoModulePath changes the path of the module generated into dir if it is not the path,
+// and rewrites the imports of its packages. jsii-pacmak derives the major version suffix
+// from the package version, which differs from the path with an explicit major version.
+func setGoModulePath(dir, path string) error {
+	goModPath := filepath.Join(dir, "go.mod")
+	data, err := os.ReadFile(goModPath)
+	if err != nil {
+		return errors.Wrap(err, "read go.mod file")
+	}
+	f, err := modfile.Parse(goModPath, data, nil)
+	if err != nil {
+		return errors.Wrap(err, "parse go.mod file")
+	}
+	if f.Module == nil {
+		return errors.New("go.mod file has no module directive")
+	}
+	oldPath := f.Module.Mod.Path
+	if oldPath == path {
+		return nil
+	}
+	if err := f.AddModuleStmt(path); err != nil {
+		return errors.Wrap(err, "set module directive")
+	}
+	out, err := f.Format()
+	if err != nil {
+		return errors.Wrap(err, "format go.mod file")
+	}
+	if err := os.WriteFile(goModPath, out, 0644); err != nil {
+		return errors.Wrap(err, "write go.mod file")
+	}
+	return rewriteGoImports(dir, strings.NewReplacer(
+		`"`+oldPath+`"`, `"`+path+`"`,
+		`"`+oldPath+`/`, `"`+path+`/`,
+	))
+}
+
+// applyGoMod applies the directives of the config to the go.mod file of the module generated into dir
+func applyGoMod(dir string, goMod *generator.GoMod) error {
+	goModPath := filepath.Join(dir, "go.mod")
+	data, err := os.ReadFile(goModPath)
+	if err != nil {
+		return errors.Wrap(err, "read go.mod file")
+	}
+	f, err := modfile.Parse(goModPath, data, nil)
+	if err != nil {
+		return errors.Wrap(err, "parse go.mod file")
+	}
+	if goMod.Go != "" {
+		if err := f.AddGoStmt(goMod.Go); err != nil {
+			return errors.Wrap(err, "set go directive")
+		}
+	}
+	if goMod.Toolchain != "" {
+		if err := f.AddToolchainStmt(goMod.Toolchain); err != nil {
+			return errors.Wrap(err, "set toolchain directive")
+		}
+	}
+	for _, r := range goMod.Replace {
+		if err := f.AddReplace(r.Path, r.Version, r.NewPath, r.NewVersion); err != nil {
+			return errors.Wrapf(err, "add replace directive of %s", r.Path)
+		}
+	}
+	for _, r := range goMod.Retract {
+		vi := modfile.VersionInterval{Low: r.Low, High: r.High}
+		if vi.High == "" {
+			vi.High = vi.Low
+		}
+		// unlike the other directives, retractions are not replaced by AddRetract
+		if err := f.DropRetract(vi); err != nil {
+			return errors.Wrapf(err, "drop retract directive of %s", r.Low)
+		}
+		if err := f.AddRetract(vi, r.Rationale); err != nil {
+			return errors.Wrapf(err, "add retract directive of %s", r.Low)
+		}
+	}
+	for _, e := range goMod.Exclude {
+		if err := f.AddExclude(e.Path, e.Version); err != nil {
+			return errors.Wrapf(err, "add exclude directive of %s", e.Path)
+		}
+	}
+	f.Cleanup()
+	out, err := f.Format()
+	if err != nil {
+		return errors.Wrap(err, "format go.mod file")
+	}
+	return errors.Wrap(os.WriteFile(goModPath, out, 0644), "write go.mod file")
+}
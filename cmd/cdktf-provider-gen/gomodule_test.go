@@ -0,0 +1,171 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/mod/modfile"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/generator"
+)
+
+func TestGoModule(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  generator.GoTarget
+		version string
+		want    autogold.Value
+	}{
+		{
+			name:    "no suffix by default",
+			target:  generator.GoTarget{ModuleName: "github.com/your-org/gen", PackageName: "google"},
+			version: "4.69.1",
+			want:    autogold.Expect([]any{0, "github.com/your-org/gen/google", "v0.0.0"}),
+		},
+		{
+			name:    "derived suffix",
+			target:  generator.GoTarget{ModuleName: "github.com/your-org/gen", PackageName: "google", MajorVersionSuffix: true},
+			version: "4.69.1",
+			want:    autogold.Expect([]any{4, "github.com/your-org/gen/google/v4", "v4.69.1"}),
+		},
+		{
+			name:    "derived major version 1 has no suffix",
+			target:  generator.GoTarget{ModuleName: "github.com/your-org/gen", PackageName: "random", MajorVersionSuffix: true},
+			version: "1.3.2",
+			want:    autogold.Expect([]any{1, "github.com/your-org/gen/random", "v1.3.2"}),
+		},
+		{
+			name:    "derived major version 0",
+			target:  generator.GoTarget{ModuleName: "github.com/your-org/gen", PackageName: "example", MajorVersionSuffix: true},
+			version: "0.4.0",
+			want:    autogold.Expect([]any{0, "github.com/your-org/gen/example", "v0.4.0"}),
+		},
+		{
+			name:    "explicit major version",
+			target:  generator.GoTarget{ModuleName: "github.com/your-org/gen", PackageName: "google", MajorVersion: 5},
+			version: "4.69.1",
+			want:    autogold.Expect([]any{5, "github.com/your-org/gen/google/v5", "v5.69.1"}),
+		},
+		{
+			name:    "explicit major version takes precedence",
+			target:  generator.GoTarget{ModuleName: "github.com/your-org/gen", PackageName: "google", MajorVersion: 1, MajorVersionSuffix: true},
+			version: "4.69.1",
+			want:    autogold.Expect([]any{1, "github.com/your-org/gen/google", "v1.69.1"}),
+		},
+		{
+			name:    "version suffix",
+			target:  generator.GoTarget{ModuleName: "github.com/your-org/gen", PackageName: "google", MajorVersionSuffix: true, VersionSuffix: "-devpreview"},
+			version: "5.0.0",
+			want:    autogold.Expect([]any{5, "github.com/your-org/gen/google/v5", "v5.0.0-devpreview"}),
+		},
+		{
+			name:    "prerelease version",
+			target:  generator.GoTarget{ModuleName: "github.com/your-org/gen", PackageName: "google", MajorVersionSuffix: true},
+			version: "5.0.0-beta1",
+			want:    autogold.Expect([]any{5, "github.com/your-org/gen/google/v5", "v5.0.0-beta1"}),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.want.Equal(t, []any{
+				goMajorVersion(&tc.target, tc.version),
+				goModulePath(&tc.target, tc.version),
+				goModuleVersion(&tc.target, tc.version),
+			})
+		})
+	}
+}
+
+func TestApplyGoMod(t *testing.T) {
+	dir := t.TempDir()
+	writeGeneratedGoModule(t, dir, "github.com/your-org/gen/google")
+	goMod := &generator.GoMod{
+		Go:        "1.22",
+		Toolchain: "go1.22.5",
+		Replace: []generator.GoModReplace{
+			{Path: "github.com/aws/jsii-runtime-go", NewPath: "github.com/your-org/jsii-runtime-go", NewVersion: "v1.97.1"},
+			{Path: "github.com/hashicorp/terraform-cdk-go/cdktf", Version: "v0.17.0", NewPath: "../cdktf"},
+		},
+		Retract: []generator.GoModRetract{
+			{Low: "v0.1.0", Rationale: "broken provider schema"},
+			{Low: "v0.2.0", High: "v0.2.3"},
+		},
+		Exclude: []generator.GoModExclude{
+			{Path: "github.com/aws/constructs-go/constructs/v10", Version: "v10.2.0"},
+		},
+	}
+	require.NoError(t, applyGoMod(dir, goMod))
+
+	got, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	require.NoError(t, err)
+	autogold.Expect(`module github.com/your-org/gen/google
+
+go 1.22
+
+toolchain go1.22.5
+
+require github.com/aws/jsii-runtime-go v1.97.0
+
+replace github.com/aws/jsii-runtime-go => github.com/your-org/jsii-runtime-go v1.97.1
+
+exclude (
+	github.com/aws/constructs-go/constructs/v10 v10.1.0
+	github.com/aws/constructs-go/constructs/v10 v10.2.0
+)
+
+replace github.com/hashicorp/terraform-cdk-go/cdktf v0.17.0 => ../cdktf
+
+retract (
+	// broken provider schema
+	v0.1.0
+	[v0.2.0, v0.2.3]
+)
+`).Equal(t, string(got))
+
+	t.Run("round trip", func(t *testing.T) {
+		// applying the directives again changes nothing
+		require.NoError(t, applyGoMod(dir, goMod))
+		again, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		require.NoError(t, err)
+		assert.Equal(t, string(got), string(again))
+
+		f, err := modfile.Parse("go.mod", again, nil)
+		require.NoError(t, err)
+		out, err := f.Format()
+		require.NoError(t, err)
+		assert.Equal(t, string(got), string(out))
+	})
+
+	t.Run("split modules", func(t *testing.T) {
+		// every split module has the directives but the retractions
+		require.NoError(t, splitGoModules(dir, &generator.GoSplit{Version: "v0.3.0"}))
+		got, err := os.ReadFile(filepath.Join(dir, "storagebucket", "go.mod"))
+		require.NoError(t, err)
+		autogold.Expect(`module github.com/your-org/gen/google/storagebucket
+
+go 1.22
+
+toolchain go1.22.5
+
+require (
+	github.com/aws/jsii-runtime-go v1.97.0
+	github.com/your-org/gen/google v0.3.0
+)
+
+replace github.com/aws/jsii-runtime-go => github.com/your-org/jsii-runtime-go v1.97.1
+
+replace github.com/hashicorp/terraform-cdk-go/cdktf v0.17.0 => ../../cdktf
+
+exclude (
+	github.com/aws/constructs-go/constructs/v10 v10.1.0
+	github.com/aws/constructs-go/constructs/v10 v10.2.0
+)
+
+replace github.com/your-org/gen/google => ..
+`).Equal(t, string(got))
+	})
+}
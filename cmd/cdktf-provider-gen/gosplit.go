@@ -0,0 +1,195 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/generator"
+)
+
+// splitGoModules splits the Go package generated by jsii-pacmak into distDir into multiple
+// modules. The namespace packages of a group are moved into the directory of the group and
+// their imports are rewritten, every other namespace package is a module on its own.
+// Every module requires the core module, i.e., the go.mod of distDir, at the split version,
+// and has its go, toolchain, replace and exclude directives. Retractions only apply to the core module.
+// The modules have the major version suffix of the core module, e.g., the namespace package
+// <core>/v4/storagebucket becomes the module <core>/storagebucket/v4, since they share its version.
+func splitGoModules(distDir string, split *generator.GoSplit) error {
+	corePath := filepath.Join(distDir, "go.mod")
+	data, err := os.ReadFile(corePath)
+	if err != nil {
+		return errors.Wrap(err, "read go.mod file")
+	}
+	core, err := modfile.Parse(corePath, data, nil)
+	if err != nil {
+		return errors.Wrap(err, "parse go.mod file")
+	}
+	if core.Module == nil {
+		return errors.New("go.mod file has no module directive")
+	}
+	coreModule := core.Module.Mod.Path
+	if err := module.Check(coreModule, split.Version); err != nil {
+		return errors.Wrap(err, "invalid core module version, the major version must match the major version suffix of the module, see majorVersion")
+	}
+	prefix, pathMajor, _ := module.SplitPathVersion(coreModule)
+
+	namespaces, err := goNamespaces(distDir)
+	if err != nil {
+		return err
+	}
+	isNamespace := make(map[string]bool, len(namespaces))
+	for _, ns := range namespaces {
+		isNamespace[ns] = true
+	}
+	for _, g := range split.Groups {
+		if isNamespace[g.Name] {
+			return errors.Newf("group %q has the same name as a namespace package", g.Name)
+		}
+	}
+
+	modules := make(map[string]bool)
+	// the import paths of the moved packages and of the packages of modules with a suffix
+	var renames []string
+	for _, ns := range namespaces {
+		oldPath, newPath := coreModule+"/"+ns, goSplitModulePath(prefix, ns, pathMajor)
+		if group := goModuleGroup(split.Groups, ns); group != "" {
+			modules[group] = true
+			if err := os.MkdirAll(filepath.Join(distDir, group), 0755); err != nil {
+				return errors.Wrapf(err, "create group %q", group)
+			}
+			if err := os.Rename(filepath.Join(distDir, ns), filepath.Join(distDir, group, ns)); err != nil {
+				return errors.Wrapf(err, "move %q to group %q", ns, group)
+			}
+			newPath = goSplitModulePath(prefix, group, pathMajor) + "/" + ns
+		} else {
+			modules[ns] = true
+		}
+		if oldPath != newPath {
+			renames = append(renames,
+				`"`+oldPath+`"`, `"`+newPath+`"`,
+				`"`+oldPath+`/`, `"`+newPath+`/`,
+			)
+		}
+	}
+	if len(renames) > 0 {
+		if err := rewriteGoImports(distDir, strings.NewReplacer(renames...)); err != nil {
+			return err
+		}
+	}
+
+	for dir := range modules {
+		f := new(modfile.File)
+		if err := f.AddModuleStmt(goSplitModulePath(prefix, dir, pathMajor)); err != nil {
+			return errors.Wrap(err, "add module directive")
+		}
+		if core.Go != nil {
+			if err := f.AddGoStmt(core.Go.Version); err != nil {
+				return errors.Wrap(err, "add go directive")
+			}
+		}
+		if core.Toolchain != nil {
+			if err := f.AddToolchainStmt(core.Toolchain.Name); err != nil {
+				return errors.Wrap(err, "add toolchain directive")
+			}
+		}
+		for _, r := range core.Require {
+			f.AddNewRequire(r.Mod.Path, r.Mod.Version, r.Indirect)
+		}
+		for _, r := range core.Replace {
+			newPath := r.New.Path
+			if modfile.IsDirectoryPath(newPath) && !filepath.IsAbs(newPath) {
+				// relative to the core module, which is the parent directory
+				newPath = filepath.ToSlash(filepath.Join("..", newPath))
+			}
+			if err := f.AddReplace(r.Old.Path, r.Old.Version, newPath, r.New.Version); err != nil {
+				return errors.Wrap(err, "add replace directive")
+			}
+		}
+		for _, e := range core.Exclude {
+			if err := f.AddExclude(e.Mod.Path, e.Mod.Version); err != nil {
+				return errors.Wrap(err, "add exclude directive")
+			}
+		}
+		f.AddNewRequire(coreModule, split.Version, false)
+		// every module is a direct sub-directory of the core module
+		if err := f.AddReplace(coreModule, "", "..", ""); err != nil {
+			return errors.Wrap(err, "add replace directive")
+		}
+		f.SortBlocks()
+		f.Cleanup()
+		out, err := f.Format()
+		if err != nil {
+			return errors.Wrapf(err, "format go.mod file of %q", dir)
+		}
+		if err := os.WriteFile(filepath.Join(distDir, dir, "go.mod"), out, 0644); err != nil {
+			return errors.Wrapf(err, "write go.mod file of %q", dir)
+		}
+	}
+	return nil
+}
+
+// goSplitModulePath returns the path of the module split into the dir of the core module,
+// prefix and pathMajor are the path of the core module without and its major version suffix
+func goSplitModulePath(prefix, dir, pathMajor string) string {
+	return prefix + "/" + dir + pathMajor
+}
+
+// goNamespaces returns the namespace packages of the generated Go code in dir,
+// i.e., every directory with Go files that is not kept in the core module
+func goNamespaces(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "read generated go package")
+	}
+	var namespaces []string
+	for _, entry := range entries {
+		if !entry.IsDir() || generator.CoreGoPackages[entry.Name()] {
+			continue
+		}
+		files, err := filepath.Glob(filepath.Join(dir, entry.Name(), "*.go"))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) > 0 {
+			namespaces = append(namespaces, entry.Name())
+		}
+	}
+	return namespaces, nil
+}
+
+// goModuleGroup returns the name of the first group of the namespace package, if any
+func goModuleGroup(groups []generator.GoModuleGroup, ns string) string {
+	for _, g := range groups {
+		for _, pattern := range g.Packages {
+			if ok, _ := filepath.Match(pattern, ns); ok {
+				return g.Name
+			}
+		}
+	}
+	return ""
+}
+
+// rewriteGoImports rewrites the quoted import paths of every Go file in dir
+func rewriteGoImports(dir string, r *strings.Replacer) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".go" {
+			return err
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return errors.Wrap(err, "read go file")
+		}
+		if rewritten := r.Replace(string(b)); rewritten != string(b) {
+			if err := os.WriteFile(path, []byte(rewritten), 0644); err != nil {
+				return errors.Wrap(err, "write go file")
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/generator"
+)
+
+// writeGeneratedGoModule writes a small Go module generated by jsii-pacmak with the module path into dir
+func writeGeneratedGoModule(t *testing.T, dir, modulePath string) {
+	t.Helper()
+	files := map[string]string{
+		"go.mod": `module ` + modulePath + `
+
+go 1.18
+
+require github.com/aws/jsii-runtime-go v1.97.0
+
+replace github.com/aws/jsii-runtime-go => ./jsii-runtime-go
+
+exclude github.com/aws/constructs-go/constructs/v10 v10.1.0
+`,
+		"jsii/jsii.go":                  "package jsii\n",
+		"provider/provider.go":          "package provider\n\nimport _ \"" + modulePath + "/jsii\"\n",
+		"computedisk/disk.go":           "package computedisk\n\nimport _ \"" + modulePath + "/computedisk/internal\"\n",
+		"computedisk/internal/types.go": "package internal\n",
+		"computeinstance/instance.go":   "package computeinstance\n\nimport (\n\t_ \"" + modulePath + "/computedisk\"\n\t_ \"" + modulePath + "/computedisk/internal\"\n\t_ \"" + modulePath + "/jsii\"\n)\n",
+		"storagebucket/bucket.go":       "package storagebucket\n\nimport _ \"" + modulePath + "/jsii\"\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+// readGoModuleFiles returns the content of the go.mod and Go files of dir by their slash separated path
+func readGoModuleFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	require.NoError(t, filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(b)
+		return nil
+	}))
+	return files
+}
+
+func TestSplitGoModules(t *testing.T) {
+	tests := []struct {
+		name       string
+		modulePath string
+		split      generator.GoSplit
+		want       autogold.Value
+	}{
+		{
+			name:       "namespaces",
+			modulePath: "github.com/your-org/gen/google",
+			split:      generator.GoSplit{Version: "v0.1.0"},
+			want: autogold.Expect(map[string]string{
+				"computedisk/disk.go": `package computedisk
+
+import _ "github.com/your-org/gen/google/computedisk/internal"
+`, "computedisk/go.mod": `module github.com/your-org/gen/google/computedisk
+
+go 1.18
+
+require (
+	github.com/aws/jsii-runtime-go v1.97.0
+	github.com/your-org/gen/google v0.1.0
+)
+
+replace github.com/aws/jsii-runtime-go => ../jsii-runtime-go
+
+exclude github.com/aws/constructs-go/constructs/v10 v10.1.0
+
+replace github.com/your-org/gen/google => ..
+`, "computedisk/internal/types.go": "package internal\n",
+				"computeinstance/go.mod": `module github.com/your-org/gen/google/computeinstance
+
+go 1.18
+
+require (
+	github.com/aws/jsii-runtime-go v1.97.0
+	github.com/your-org/gen/google v0.1.0
+)
+
+replace github.com/aws/jsii-runtime-go => ../jsii-runtime-go
+
+exclude github.com/aws/constructs-go/constructs/v10 v10.1.0
+
+replace github.com/your-org/gen/google => ..
+`,
+				"computeinstance/instance.go": `package computeinstance
+
+import (
+	_ "github.com/your-org/gen/google/computedisk"
+	_ "github.com/your-org/gen/google/computedisk/internal"
+	_ "github.com/your-org/gen/google/jsii"
+)
+`,
+				"go.mod": `module github.com/your-org/gen/google
+
+go 1.18
+
+require github.com/aws/jsii-runtime-go v1.97.0
+
+replace github.com/aws/jsii-runtime-go => ./jsii-runtime-go
+
+exclude github.com/aws/constructs-go/constructs/v10 v10.1.0
+`,
+				"jsii/jsii.go": "package jsii\n",
+				"provider/provider.go": `package provider
+
+import _ "github.com/your-org/gen/google/jsii"
+`,
+				"storagebucket/bucket.go": `package storagebucket
+
+import _ "github.com/your-org/gen/google/jsii"
+`,
+				"storagebucket/go.mod": `module github.com/your-org/gen/google/storagebucket
+
+go 1.18
+
+require (
+	github.com/aws/jsii-runtime-go v1.97.0
+	github.com/your-org/gen/google v0.1.0
+)
+
+replace github.com/aws/jsii-runtime-go => ../jsii-runtime-go
+
+exclude github.com/aws/constructs-go/constructs/v10 v10.1.0
+
+replace github.com/your-org/gen/google => ..
+`,
+			}),
+		},
+		{
+			name:       "group",
+			modulePath: "github.com/your-org/gen/google",
+			split: generator.GoSplit{
+				Version: "v0.1.0",
+				Groups:  []generator.GoModuleGroup{{Name: "compute", Packages: []string{"compute*"}}},
+			},
+			want: autogold.Expect(map[string]string{
+				"compute/computedisk/disk.go": `package computedisk
+
+import _ "github.com/your-org/gen/google/compute/computedisk/internal"
+`, "compute/computedisk/internal/types.go": "package internal\n",
+				"compute/computeinstance/instance.go": `package computeinstance
+
+import (
+	_ "github.com/your-org/gen/google/compute/computedisk"
+	_ "github.com/your-org/gen/google/compute/computedisk/internal"
+	_ "github.com/your-org/gen/google/jsii"
+)
+`,
+				"compute/go.mod": `module github.com/your-org/gen/google/compute
+
+go 1.18
+
+require (
+	github.com/aws/jsii-runtime-go v1.97.0
+	github.com/your-org/gen/google v0.1.0
+)
+
+replace github.com/aws/jsii-runtime-go => ../jsii-runtime-go
+
+exclude github.com/aws/constructs-go/constructs/v10 v10.1.0
+
+replace github.com/your-org/gen/google => ..
+`,
+				"go.mod": `module github.com/your-org/gen/google
+
+go 1.18
+
+require github.com/aws/jsii-runtime-go v1.97.0
+
+replace github.com/aws/jsii-runtime-go => ./jsii-runtime-go
+
+exclude github.com/aws/constructs-go/constructs/v10 v10.1.0
+`,
+				"jsii/jsii.go": "package jsii\n",
+				"provider/provider.go": `package provider
+
+import _ "github.com/your-org/gen/google/jsii"
+`,
+				"storagebucket/bucket.go": `package storagebucket
+
+import _ "github.com/your-org/gen/google/jsii"
+`,
+				"storagebucket/go.mod": `module github.com/your-org/gen/google/storagebucket
+
+go 1.18
+
+require (
+	github.com/aws/jsii-runtime-go v1.97.0
+	github.com/your-org/gen/google v0.1.0
+)
+
+replace github.com/aws/jsii-runtime-go => ../jsii-runtime-go
+
+exclude github.com/aws/constructs-go/constructs/v10 v10.1.0
+
+replace github.com/your-org/gen/google => ..
+`,
+			}),
+		},
+		{
+			name:       "major version suffix",
+			modulePath: "github.com/your-org/gen/google/v4",
+			split: generator.GoSplit{
+				Version: "v4.69.1",
+				Groups:  []generator.GoModuleGroup{{Name: "compute", Packages: []string{"compute*"}}},
+			},
+			want: autogold.Expect(map[string]string{
+				"compute/computedisk/disk.go": `package computedisk
+
+import _ "github.com/your-org/gen/google/compute/v4/computedisk/internal"
+`, "compute/computedisk/internal/types.go": "package internal\n",
+				"compute/computeinstance/instance.go": `package computeinstance
+
+import (
+	_ "github.com/your-org/gen/google/compute/v4/computedisk"
+	_ "github.com/your-org/gen/google/compute/v4/computedisk/internal"
+	_ "github.com/your-org/gen/google/v4/jsii"
+)
+`,
+				"compute/go.mod": `module github.com/your-org/gen/google/compute/v4
+
+go 1.18
+
+require (
+	github.com/aws/jsii-runtime-go v1.97.0
+	github.com/your-org/gen/google/v4 v4.69.1
+)
+
+replace github.com/aws/jsii-runtime-go => ../jsii-runtime-go
+
+exclude github.com/aws/constructs-go/constructs/v10 v10.1.0
+
+replace github.com/your-org/gen/google/v4 => ..
+`,
+				"go.mod": `module github.com/your-org/gen/google/v4
+
+go 1.18
+
+require github.com/aws/jsii-runtime-go v1.97.0
+
+replace github.com/aws/jsii-runtime-go => ./jsii-runtime-go
+
+exclude github.com/aws/constructs-go/constructs/v10 v10.1.0
+`,
+				"jsii/jsii.go": "package jsii\n",
+				"provider/provider.go": `package provider
+
+import _ "github.com/your-org/gen/google/v4/jsii"
+`,
+				"storagebucket/bucket.go": `package storagebucket
+
+import _ "github.com/your-org/gen/google/v4/jsii"
+`,
+				"storagebucket/go.mod": `module github.com/your-org/gen/google/storagebucket/v4
+
+go 1.18
+
+require (
+	github.com/aws/jsii-runtime-go v1.97.0
+	github.com/your-org/gen/google/v4 v4.69.1
+)
+
+replace github.com/aws/jsii-runtime-go => ../jsii-runtime-go
+
+exclude github.com/aws/constructs-go/constructs/v10 v10.1.0
+
+replace github.com/your-org/gen/google/v4 => ..
+`,
+			}),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeGeneratedGoModule(t, dir, tc.modulePath)
+
+			require.NoError(t, splitGoModules(dir, &tc.split))
+			tc.want.Equal(t, readGoModuleFiles(t, dir))
+		})
+	}
+
+	t.Run("group with the name of a namespace", func(t *testing.T) {
+		dir := t.TempDir()
+		writeGeneratedGoModule(t, dir, "github.com/your-org/gen/google")
+
+		err := splitGoModules(dir, &generator.GoSplit{
+			Version: "v0.1.0",
+			Groups:  []generator.GoModuleGroup{{Name: "storagebucket", Packages: []string{"compute*"}}},
+		})
+		require.Error(t, err)
+		autogold.Expect(`group "storagebucket" has the same name as a namespace package`).Equal(t, err.Error())
+	})
+
+	t.Run("version of another major version", func(t *testing.T) {
+		dir := t.TempDir()
+		writeGeneratedGoModule(t, dir, "github.com/your-org/gen/google")
+
+		err := splitGoModules(dir, &generator.GoSplit{Version: "v4.69.1"})
+		require.Error(t, err)
+		autogold.Expect("invalid core module version, the major version must match the major version suffix of the module, see majorVersion: github.com/your-org/gen/google@v4.69.1: invalid version: should be v0 or v1, not v4").Equal(t, err.Error())
+	})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	hcversion "github.com/hashicorp/go-version"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/cdktf"
+)
+
+// localProviderMirror returns the absolute path of the filesystem mirror the local provider
+// is installed from. A provider binary is linked into a mirror in the unpacked layout in dir.
+// https://developer.hashicorp.com/terraform/cli/config/config-file#filesystem_mirror
+func localProviderMirror(provider *cdktf.Source, addr cdktf.ProviderAddress, dir string) (string, error) {
+	if provider.Mirror != "" {
+		mirror, err := filepath.Abs(provider.Mirror)
+		if err != nil {
+			return "", errors.Wrapf(err, "resolve provider mirror %q", provider.Mirror)
+		}
+		return mirror, nil
+	}
+
+	binary, err := filepath.Abs(provider.Binary)
+	if err != nil {
+		return "", errors.Wrapf(err, "resolve provider binary %q", provider.Binary)
+	}
+	if _, err := os.Stat(binary); err != nil {
+		return "", errors.Wrap(err, "provider binary")
+	}
+	mirror := filepath.Join(dir, "providers")
+	platformDir := filepath.Join(mirror, addr.Hostname, addr.Namespace, addr.Type, provider.Version, runtime.GOOS+"_"+runtime.GOARCH)
+	if err := os.MkdirAll(platformDir, 0755); err != nil {
+		return "", errors.Wrap(err, "create provider mirror dir")
+	}
+	name := fmt.Sprintf("terraform-provider-%s_v%s", addr.Type, provider.Version)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	if err := os.Symlink(binary, filepath.Join(platformDir, name)); err != nil {
+		return "", errors.Wrap(err, "link provider binary")
+	}
+	return mirror, nil
+}
+
+// mirrorVersions returns every version of the provider in the filesystem mirror,
+// in either the unpacked or the packed layout
+func mirrorVersions(mirror string, addr cdktf.ProviderAddress) ([]*hcversion.Version, error) {
+	entries, err := os.ReadDir(filepath.Join(mirror, addr.Hostname, addr.Namespace, addr.Type))
+	if err != nil {
+		return nil, errors.Wrapf(err, "list versions of provider %q in mirror", addr)
+	}
+	packedPrefix := "terraform-provider-" + addr.Type + "_"
+	seen := make(map[string]bool)
+	var versions []*hcversion.Version
+	for _, e := range entries {
+		// unpacked: <version>/<os>_<arch>/, packed: terraform-provider-<type>_<version>_<os>_<arch>.zip
+		raw := e.Name()
+		if !e.IsDir() {
+			rest, ok := strings.CutPrefix(raw, packedPrefix)
+			if !ok || !strings.HasSuffix(rest, ".zip") {
+				continue
+			}
+			raw, _, _ = strings.Cut(rest, "_")
+		}
+		v, err := hcversion.NewVersion(raw)
+		if err != nil || seen[v.String()] {
+			continue
+		}
+		seen[v.String()] = true
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
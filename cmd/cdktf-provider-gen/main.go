@@ -1,25 +1,15 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	_ "embed"
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"net/url"
 	"os"
-	"path/filepath"
 	"sort"
-	"strings"
 	"text/template"
 
-	hcversion "github.com/hashicorp/go-version"
-	hcproduct "github.com/hashicorp/hc-install/product"
-	tfreleases "github.com/hashicorp/hc-install/releases"
-	cp "github.com/otiai10/copy"
 	"github.com/sourcegraph/log"
-	"github.com/sourcegraph/run"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/mod/modfile"
@@ -27,8 +17,8 @@ import (
 
 	"github.com/sourcegraph/cdktf-provider-gen/internal/observability"
 	"github.com/sourcegraph/cdktf-provider-gen/internal/output"
-	"github.com/sourcegraph/cdktf-provider-gen/pkg/cdktf"
 	"github.com/sourcegraph/cdktf-provider-gen/pkg/generator"
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/registry"
 )
 
 func main() {
@@ -48,12 +38,22 @@ var (
 	//go:embed package.json
 	packageJSONTemplateString string
 	packageJSONTemplate       = template.Must(template.New("").Parse(packageJSONTemplateString))
+
+	//go:embed generate-from-schema.js
+	generateFromSchemaScript []byte
 )
 
 type projectTemplateData struct {
-	Config      generator.Config
+	Config generator.Config
+	// PackageName is the name of the npm package
 	PackageName string
-	ModuleName  string
+	// PackageVersion is the version of the jsii project, see jsiiProjectVersion
+	PackageVersion string
+	Targets        []languageTarget
+	// JsiiTargets is the JSON of the `jsii.targets` config of every target
+	JsiiTargets string
+	// ProviderAddress is the fully qualified address of the provider in its schema file
+	ProviderAddress string
 
 	Deps cdktfDependencies
 }
@@ -62,174 +62,185 @@ var gen = &cli.App{
 	Name: "cdktf-provider-gen",
 	Flags: []cli.Flag{
 		configFlag,
+		defaultsFlag,
+		setFlag,
 		cdktfVersionFlag,
+		toolchainFlag,
+		terraformVersionFlag,
+		openTofuVersionFlag,
+		terraformBinaryFlag,
+		terraformFromPathFlag,
+		toolchainCacheDirFlag,
 		keepFlag,
+		concurrencyFlag,
+		registryURLFlag,
+		credentialsFileFlag,
+		bundleFlag,
+		offlineFlag,
+	},
+	Commands: []*cli.Command{
+		validateCommand,
+		prefetchCommand,
 	},
 	UsageText: `
 # Generate the googla provider
 cdktf-provider-gen -concifg google.yaml
 
-# Use a specific version of cdktf
-cdktf-provider-gen -config google.yaml -cdktf-version 0.17.3
-    `,
-	Action: func(c *cli.Context) error {
-		logger := log.Scoped("gen")
+# Resolve version constraints against a local registry
+cdktf-provider-gen -config google.yaml -registry-url http://localhost:8080
 
-		// TODO: add validation
-		cdktfVersion := cdktfVersionFlag.Get(c)
-		logger = logger.With(log.String("cdktf.version", cdktfVersion))
+# Use a specific version of cdktf and terraform for configs without one
+cdktf-provider-gen -config google.yaml -cdktf-version 0.17.3 -terraform-version 1.8.5
 
-		// workarounad for lack of well supported terraform toolchains for bazel
-		// so we need to bring our own terraform and configure it in the path
-		// so the cdktf-cli npm package can access it
-		tfInstallDir, err := os.MkdirTemp("", "tf-bin")
-		if err != nil {
-			return errors.Wrap(err, "create temp tf-bin dir")
-		}
-		defer os.RemoveAll(tfInstallDir)
-		installer := &tfreleases.ExactVersion{
-			Product: hcproduct.Terraform,
-			Version: hcversion.Must(hcversion.NewVersion("1.5.5")),
-		}
-		installer.InstallDir = tfInstallDir
-		_, err = installer.Install(c.Context)
-		if err != nil {
-			return errors.Wrap(err, "install terraform")
-		}
-		_ = os.Setenv("PATH", tfInstallDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+# Fetch schemas with OpenTofu instead of terraform
+cdktf-provider-gen -config google.yaml -toolchain opentofu
 
-		b, err := os.ReadFile(configFlag.Get(c))
-		if err != nil {
-			return errors.Wrap(err, "read config file")
-		}
-		config, err := generator.NewConfig(b)
-		if err != nil {
-			return errors.Wrapf(err, "parse config file %q", configFlag.Get(c))
-		}
+# Reuse the terraform binary on PATH if it is the requested version
+cdktf-provider-gen -config google.yaml -terraform-from-path
 
-		logger = logger.With(log.String("name", config.Name))
-		if config.Provider != nil {
-			logger = logger.With(
-				log.String("name", config.Name),
-				log.String("provider.name", config.Provider.Name),
-				log.String("provider.version", config.Provider.Version),
-			)
-		}
-		if config.Module != nil {
-			logger = logger.With(
-				log.String("module.source", config.Module.Source),
-				log.String("module.version", config.Module.Version),
-			)
-		}
+# Override the cdktf version of every config
+cdktf-provider-gen -config providers.yaml -set cdktfVersion=0.20.8
 
-		m := cdktf.Manifest{
-			Language:         "typescript",
-			App:              "echo noop",
-			SendCrashReports: false,
-			ProjectID:        "noop",
-		}
-		if config.Provider != nil {
-			// this is a special handling for provider name with hyphens
-			providerName, ok := Last(strings.Split(config.Provider.Source, "/"))
-			if !ok {
-				return errors.Newf("provider name not found: %q", config.Provider.Source)
-			}
-			config.Provider.Name = providerName
-			m.TerraformProviders = []cdktf.Source{
-				*config.Provider,
-			}
-		}
-		if config.Module != nil {
-			config.Module.Name = config.Name
-			m.TerraformModules = []cdktf.Source{
-				*config.Module,
-			}
-		}
-		var cdktfJSON bytes.Buffer
-		enc := json.NewEncoder(&cdktfJSON)
-		enc.SetEscapeHTML(false)
-		if err := enc.Encode(m); err != nil {
-			return errors.Wrap(err, "marshal cdktf.json")
-		}
+# Generate every config listed in a batch config file
+cdktf-provider-gen -config providers.yaml
 
-		deps, err := fetchCdktfDependencies(c.Context, cdktfVersion)
-		if err != nil {
-			return errors.Wrap(err, "fetch cdktf dependencies")
-		}
-		deps.Cdktf = cdktfVersion
+# Merge a shared defaults file into every config
+cdktf-provider-gen -config google.yaml -defaults ../defaults.yaml
 
-		data := projectTemplateData{
-			Config:      *config,
-			PackageName: config.Target.Go.PackageName,
-			ModuleName:  config.Target.Go.ModuleName,
-			Deps:        *deps,
-		}
-		var packageJSON bytes.Buffer
-		if err := packageJSONTemplate.Execute(&packageJSON, data); err != nil {
-			return errors.Wrap(err, "render package.json")
-		}
+# Override a value of every config, e.g., from CI
+cdktf-provider-gen -config google.yaml -set provider.version=4.70.0
 
-		tmpDir, err := os.MkdirTemp("", "cdktfprovidergen")
-		if err != nil {
-			return errors.Wrap(err, "create temp dir")
-		}
-		if !keepFlag.Get(c) {
-			defer os.RemoveAll(tmpDir)
-		}
-		logger = logger.With(log.String("tmpDir", tmpDir))
+# Generate several config files in one run, up to 4 at the same time
+cdktf-provider-gen -config google.yaml -config aws.yaml -concurrency 4
 
-		logger.Debug("write package.json")
-		if err := os.WriteFile(filepath.Join(tmpDir, "package.json"), packageJSON.Bytes(), 0644); err != nil {
-			return errors.Wrap(err, "write package.json")
-		}
-		logger.Debug("write cdktf.json")
-		if err := os.WriteFile(filepath.Join(tmpDir, "cdktf.json"), cdktfJSON.Bytes(), 0644); err != nil {
-			return errors.Wrap(err, "write cdktf.json")
+# Generate without network access from a bundle filled by the prefetch command
+cdktf-provider-gen -config google.yaml -offline -bundle ./bundle
+    `,
+	Action: func(c *cli.Context) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return errors.Wrap(err, "get working dir")
 		}
-
-		logger.Debug("compiling cdktf provider code")
-		cmdCtx := observability.LogCommands(c.Context, logger)
-		for _, cmd := range []string{
-			"npm install --no-save",
-			"npm run fetch",
-			"npm run compile",
-			"rm -rf ./src", // remove the source code dir `./src`, we only need `./lib`, shave off a few extra bytes
-			"npm run pkg:go",
-		} {
-			if err := run.Cmd(cmdCtx, cmd).Dir(tmpDir).Run().Wait(); err != nil {
-				return errors.Wrapf(err, "run: %q", cmd)
+		var b *bundle
+		switch {
+		case offlineFlag.Get(c):
+			if b, err = newBundle(bundleFlag.Get(c), true); err != nil {
+				return err
 			}
+			if err := b.check(); err != nil {
+				return err
+			}
+		case bundleFlag.Get(c) != "":
+			return errors.New("-bundle requires -offline, use the prefetch command to fill a bundle")
 		}
+		return generateConfigs(c, log.Scoped("gen"), cwd, b)
+	},
+}
 
-		srcDir := filepath.Join(tmpDir, "dist", "go", config.Target.Go.PackageName)
-		logger = logger.With(log.String("srcDir", srcDir))
-		logger.Debug("pining cdktf go dependencies")
-		if err := pinCdktfGoDependencies(c.Context, cdktfVersion, fmt.Sprintf("%s/go.mod", srcDir)); err != nil {
-			return errors.Wrap(err, "pin cdktf go dependencies")
-		}
+// generateConfigs generates every config of the config files of the flags, the outputs are
+// relative to workDir. b is the bundle to generate offline from or to fill, if any.
+func generateConfigs(c *cli.Context, logger log.Logger, workDir string, b *bundle) error {
+	defaults := toolchainDefaults{
+		CdktfVersion:     cdktfVersionFlag.Get(c),
+		Terraform:        toolchainFlag.Get(c),
+		TerraformVersion: terraformVersionFlag.Get(c),
+		OpenTofuVersion:  openTofuVersionFlag.Get(c),
+	}
+	if err := generator.ValidateCdktfVersion(defaults.CdktfVersion); err != nil {
+		return errors.Wrap(err, "invalid -cdktf-version")
+	}
+	if defaults.Terraform != generator.ToolchainTerraform && defaults.Terraform != generator.ToolchainOpenTofu {
+		return errors.Newf("invalid -toolchain %q, must be %s or %s", defaults.Terraform, generator.ToolchainTerraform, generator.ToolchainOpenTofu)
+	}
+	if err := generator.ValidateTerraformVersion(defaults.TerraformVersion); err != nil {
+		return errors.Wrap(err, "invalid -terraform-version")
+	}
+	if err := generator.ValidateTerraformVersion(defaults.OpenTofuVersion); err != nil {
+		return errors.Wrap(err, "invalid -opentofu-version")
+	}
+	concurrency := concurrencyFlag.Get(c)
+	if b != nil && !b.Offline {
+		// terraform installs the providers into the plugin cache dir of the bundle, which
+		// is not safe for concurrent use, so a bundle is filled one config at a time
+		concurrency = 1
+	}
+	if concurrency < 1 {
+		return errors.Newf("concurrency must be at least 1, got %d", concurrency)
+	}
 
-		cwd, err := os.Getwd()
+	// the flag is not marked as required, otherwise subcommands would require it too
+	if len(configFlag.Get(c)) == 0 {
+		return errors.New("at least one -config is required")
+	}
+	loadOpts, err := loadOptions(c)
+	if err != nil {
+		return err
+	}
+	var configs []*generator.Config
+	for _, path := range configFlag.Get(c) {
+		cs, err := generator.LoadConfigs(path, loadOpts)
 		if err != nil {
-			return errors.Wrap(err, "get working dir")
+			return errors.Wrapf(err, "parse config file %q", path)
 		}
-		outputDir := filepath.Join(cwd, config.Output, config.Target.Go.PackageName)
-		logger = logger.With(log.String("outputDir", outputDir))
-		logger.Debug("ensuring output dir is clean")
-		if _, err := os.Stat(outputDir); err == nil {
-			if err := os.RemoveAll(outputDir); err != nil {
-				return errors.Wrapf(err, "clean output dir %q", outputDir)
-			}
+		configs = append(configs, cs...)
+	}
+
+	creds, err := loadCredentials(credentialsFileFlag.Get(c), os.Environ())
+	if err != nil {
+		return err
+	}
+	// configs without templates can't write to the same output dir, which is checked before
+	// anything is generated, the others are checked once their versions are resolved
+	outputs := &outputClaims{WorkDir: workDir}
+	for _, config := range configs {
+		if config.HasTemplates() {
+			continue
 		}
-		if err := os.MkdirAll(outputDir, 0755); err != nil {
-			return errors.Wrap(err, "create output dir")
+		if err := outputs.claim(config); err != nil {
+			return err
 		}
-		logger.Debug("copying to output dir")
-		if err := cp.Copy(srcDir, outputDir); err != nil {
-			return errors.Wrap(err, "copy cdktf.out")
+	}
+	opts := generateOptions{
+		Keep:        keepFlag.Get(c),
+		WorkDir:     workDir,
+		Registry:    &registry.Client{BaseURL: registryURLFlag.Get(c)},
+		Credentials: creds,
+		Bundle:      b,
+		Outputs:     outputs,
+	}
+
+	tf := &terraformResolver{
+		Binary:   terraformBinaryFlag.Get(c),
+		FromPath: terraformFromPathFlag.Get(c),
+		CacheDir: toolchainCacheDirFlag.Get(c),
+	}
+	switch {
+	case b.offline():
+		tf.CacheDir, tf.Offline = b.toolchainsDir(), true
+	case b != nil:
+		// always install into the bundle, a binary of the machine can't be bundled
+		tf = &terraformResolver{CacheDir: b.toolchainsDir()}
+	case tf.CacheDir == "":
+		if tf.CacheDir, err = defaultToolchainCacheDir(); err != nil {
+			return err
 		}
+	}
 
-		return nil
-	},
+	// every group of configs sharing a toolchain is generated with its own terraform
+	var summary generateSummary
+	for _, group := range groupByToolchain(configs, defaults) {
+		logger := logger.With(
+			log.String("cdktf.version", group.Toolchain.CdktfVersion),
+			log.String("terraform", group.Toolchain.Terraform),
+			log.String("terraform.version", group.Toolchain.TerraformVersion),
+		)
+		summary = append(summary, generateGroup(c.Context, logger, group, tf, opts, concurrency)...)
+	}
+	_ = output.Render(output.FormatText, summary)
+	if failed := summary.Failed(); failed > 0 {
+		return errors.Newf("%d of %d configs failed to generate", failed, len(summary))
+	}
+	return nil
 }
 
 func Last[E any](s []E) (E, bool) {
@@ -245,22 +256,19 @@ type cdktfDependencies struct {
 	JsiiPacmak string
 	Constructs string
 	Cdktf      string
+	// Codemaker is the version of codemaker @cdktf/provider-generator depends on,
+	// only set to generate the bindings from a provider schema file
+	Codemaker string
 }
 
-func fetchCdktfDependencies(ctx context.Context, version string) (*cdktfDependencies, error) {
+func fetchCdktfDependencies(ctx context.Context, b *bundle, version string) (*cdktfDependencies, error) {
 	npmAPIURL := fmt.Sprintf("https://registry.npmjs.org/cdktf/%s", version)
 
-	response, err := http.Get(npmAPIURL)
-	if err != nil {
-		return nil, errors.Wrap(err, "fetch cdktf version from registry")
-	}
-	defer response.Body.Close()
-
 	var resp struct {
 		DevDependencies map[string]string `json:"devDependencies"`
 	}
-	if err := json.NewDecoder(response.Body).Decode(&resp); err != nil {
-		return nil, errors.Wrap(err, "decode cdktf version response")
+	if err := getJSON(ctx, b, npmAPIURL, &resp); err != nil {
+		return nil, errors.Wrap(err, "fetch cdktf version from registry")
 	}
 
 	deps := &cdktfDependencies{}
@@ -282,21 +290,33 @@ func fetchCdktfDependencies(ctx context.Context, version string) (*cdktfDependen
 	return deps, nil
 }
 
+// fetchCodemakerVersion returns the version of codemaker the @cdktf/provider-generator
+// package of the cdktf version depends on
+func fetchCodemakerVersion(ctx context.Context, b *bundle, version string) (string, error) {
+	npmAPIURL := fmt.Sprintf("https://registry.npmjs.org/@cdktf/provider-generator/%s", version)
+
+	var resp struct {
+		Dependencies map[string]string `json:"dependencies"`
+	}
+	if err := getJSON(ctx, b, npmAPIURL, &resp); err != nil {
+		return "", errors.Wrap(err, "fetch @cdktf/provider-generator version from registry")
+	}
+	v, ok := resp.Dependencies["codemaker"]
+	if !ok {
+		return "", errors.New("codemaker version not found")
+	}
+	return v, nil
+}
+
 var (
 	encodedTerraformCdkGoPkgName = url.PathEscape("github.com/hashicorp/terraform-cdk-go/cdktf")
 )
 
-func fetchCdktfGoDependencies(ctx context.Context, version string) (map[string]string, error) {
+func fetchCdktfGoDependencies(ctx context.Context, b *bundle, version string) (map[string]string, error) {
 	// pkg.go.dev has no public API that can provide such information
 	// https://github.com/golang/go/issues/36785
 	depsAPIURL := fmt.Sprintf("https://api.deps.dev/v3alpha/systems/go/packages/%s/versions/v%s:dependencies", encodedTerraformCdkGoPkgName, version)
 
-	response, err := http.Get(depsAPIURL)
-	if err != nil {
-		return nil, errors.Wrap(err, "fetch cdktf go dependencies")
-	}
-	defer response.Body.Close()
-
 	var resp struct {
 		Nodes []struct {
 			VersionKey struct {
@@ -307,8 +327,8 @@ func fetchCdktfGoDependencies(ctx context.Context, version string) (map[string]s
 			Relation string `json:"relation"`
 		} `json:"nodes"`
 	}
-	if err := json.NewDecoder(response.Body).Decode(&resp); err != nil {
-		return nil, errors.Wrap(err, "decode response")
+	if err := getJSON(ctx, b, depsAPIURL, &resp); err != nil {
+		return nil, errors.Wrap(err, "fetch cdktf go dependencies")
 	}
 
 	m := make(map[string]string)
@@ -321,14 +341,17 @@ func fetchCdktfGoDependencies(ctx context.Context, version string) (map[string]s
 	return m, nil
 }
 
-func pinCdktfGoDependencies(ctx context.Context, version string, path string) error {
-	b, err := os.ReadFile(path)
+func pinCdktfGoDependencies(ctx context.Context, b *bundle, version string, path string) error {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return errors.Wrap(err, "read go.mod file")
 	}
-	modFile, err := modfile.Parse("go.mod", b, nil)
+	modFile, err := modfile.Parse(path, data, nil)
+	if err != nil {
+		return errors.Wrap(err, "parse go.mod file")
+	}
 
-	deps, err := fetchCdktfGoDependencies(ctx, version)
+	deps, err := fetchCdktfGoDependencies(ctx, b, version)
 	if err != nil {
 		return errors.Wrap(err, "fetch cdktf go dependencies")
 	}
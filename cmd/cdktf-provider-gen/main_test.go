@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/cdktf"
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/generator"
+)
+
+func TestPackageJSONTemplate(t *testing.T) {
+	config := generator.Config{
+		Name: "google",
+		Provider: &cdktf.Source{
+			Name:    "google",
+			Source:  "registry.terraform.io/hashicorp/google",
+			Version: "4.69.1",
+		},
+		Target: generator.Targets{
+			{Go: &generator.GoTarget{Language: "go", ModuleName: "github.com/sourcegraph/controller-cdktf/gen", PackageName: "google"}},
+			{TypeScript: &generator.TypeScriptTarget{Language: "typescript", Scope: "cdktf", PackageName: "provider-google"}},
+		},
+	}
+	targets, err := languageTargets(&config, "4.69.1", nil)
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	require.NoError(t, packageJSONTemplate.Execute(&b, projectTemplateData{
+		Config:         config,
+		PackageName:    npmPackageName(&config),
+		PackageVersion: jsiiProjectVersion,
+		Targets:        targets,
+		JsiiTargets:    "{}",
+	}))
+	var got struct {
+		Version string            `json:"version"`
+		Scripts map[string]string `json:"scripts"`
+	}
+	require.NoError(t, json.Unmarshal(b.Bytes(), &got))
+
+	// only the npm package is versioned with the provider
+	autogold.Expect("0.0.1").Equal(t, got.Version)
+	autogold.Expect("jsii-pacmak -v --target go").Equal(t, got.Scripts["pkg:go"])
+	autogold.Expect("npm pkg set version=4.69.1 && jsii-pacmak -v --target js && npm pkg set version=0.0.1").Equal(t, got.Scripts["pkg:js"])
+}
+
+func TestPackageJSONTemplateSchemaFile(t *testing.T) {
+	config := generator.Config{
+		Name: "google",
+		Provider: &cdktf.Source{
+			Name:       "google",
+			Source:     "registry.terraform.io/hashicorp/google",
+			Version:    "4.69.1",
+			SchemaFile: "google-schema.json",
+		},
+		Target: generator.Targets{
+			{TypeScript: &generator.TypeScriptTarget{Language: "typescript", Scope: "cdktf", PackageName: "provider-google"}},
+		},
+	}
+	targets, err := languageTargets(&config, "4.69.1", nil)
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	require.NoError(t, packageJSONTemplate.Execute(&b, projectTemplateData{
+		Config:          config,
+		PackageName:     npmPackageName(&config),
+		PackageVersion:  jsiiProjectVersion,
+		Targets:         targets,
+		JsiiTargets:     "{}",
+		ProviderAddress: "registry.terraform.io/hashicorp/google",
+		Deps:            cdktfDependencies{Cdktf: "0.17.0", Codemaker: "^1.82.0"},
+	}))
+	var got struct {
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	require.NoError(t, json.Unmarshal(b.Bytes(), &got))
+
+	// the internals generate-from-schema.js uses are pinned
+	autogold.Expect("0.17.0").Equal(t, got.DevDependencies["@cdktf/provider-generator"])
+	autogold.Expect("^1.82.0").Equal(t, got.DevDependencies["codemaker"])
+}
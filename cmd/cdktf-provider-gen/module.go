@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/generator"
+)
+
+// replaceModuleSource replaces the module source in the generated module code at path,
+// i.e., every single or double quoted string literal of the old source
+func replaceModuleSource(path, oldSource, newSource string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "read generated module")
+	}
+	code := string(b)
+	replaced := strings.NewReplacer(
+		"'"+oldSource+"'", strconv.Quote(newSource),
+		strconv.Quote(oldSource), strconv.Quote(newSource),
+	).Replace(code)
+	if replaced == code {
+		return errors.Newf("module source %q not found in the generated module", oldSource)
+	}
+	return os.WriteFile(path, []byte(replaced), 0644)
+}
+
+// writeModuleIndex writes the entrypoint of the package at path, which exports the
+// generated source file of every module
+func writeModuleIndex(path string, modules generator.Modules) error {
+	var b strings.Builder
+	for _, module := range modules {
+		fmt.Fprintf(&b, "export * from %s;\n", strconv.Quote("./"+module.Name))
+	}
+	return errors.Wrap(os.WriteFile(path, []byte(b.String()), 0644), "write module index")
+}
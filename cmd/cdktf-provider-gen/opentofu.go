@@ -0,0 +1,126 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	hcversion "github.com/hashicorp/go-version"
+	hcproduct "github.com/hashicorp/hc-install/product"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// openTofuReleasesURL is where the OpenTofu release archives are published
+const openTofuReleasesURL = "https://github.com/opentofu/opentofu/releases/download"
+
+var openTofuVersionOutputRe = regexp.MustCompile(`OpenTofu v?([0-9]+(?:\.[0-9]+)*(?:-[A-Za-z0-9.]+)?)`)
+
+// openTofu is the OpenTofu product, hc-install only knows about hashicorp products
+var openTofu = hcproduct.Product{
+	Name: "tofu",
+	BinaryName: func() string {
+		if runtime.GOOS == "windows" {
+			return "tofu.exe"
+		}
+		return "tofu"
+	},
+	GetVersion: func(ctx context.Context, path string) (*hcversion.Version, error) {
+		out, err := exec.CommandContext(ctx, path, "version").Output()
+		if err != nil {
+			return nil, err
+		}
+		submatches := openTofuVersionOutputRe.FindStringSubmatch(string(out))
+		if len(submatches) != 2 {
+			return nil, errors.Newf("unexpected version output %q", strings.TrimSpace(string(out)))
+		}
+		return hcversion.NewVersion(submatches[1])
+	},
+}
+
+// installOpenTofu downloads the OpenTofu release archive of the version for the
+// current platform, verifies its checksum and extracts the binary into dir
+func installOpenTofu(ctx context.Context, version *hcversion.Version, dir string) error {
+	archiveName := fmt.Sprintf("tofu_%s_%s_%s.zip", version, runtime.GOOS, runtime.GOARCH)
+	baseURL := fmt.Sprintf("%s/v%s", openTofuReleasesURL, version)
+
+	sums, err := download(ctx, fmt.Sprintf("%s/tofu_%s_SHA256SUMS", baseURL, version))
+	if err != nil {
+		return errors.Wrap(err, "download checksums")
+	}
+	want, err := findChecksum(sums, archiveName)
+	if err != nil {
+		return err
+	}
+	archive, err := download(ctx, baseURL+"/"+archiveName)
+	if err != nil {
+		return errors.Wrap(err, "download release archive")
+	}
+	if got := sha256.Sum256(archive); hex.EncodeToString(got[:]) != want {
+		return errors.Newf("checksum mismatch of %s", archiveName)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return errors.Wrap(err, "open release archive")
+	}
+	for _, f := range zr.File {
+		if f.Name != openTofu.BinaryName() {
+			continue
+		}
+		src, err := f.Open()
+		if err != nil {
+			return errors.Wrap(err, "open binary in release archive")
+		}
+		defer src.Close()
+		dst, err := os.OpenFile(filepath.Join(dir, f.Name), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+		if err != nil {
+			return errors.Wrap(err, "create binary")
+		}
+		defer dst.Close()
+		if _, err := io.Copy(dst, src); err != nil {
+			return errors.Wrap(err, "extract binary")
+		}
+		return dst.Close()
+	}
+	return errors.Newf("%s not found in %s", openTofu.BinaryName(), archiveName)
+}
+
+// findChecksum returns the hex encoded sha256 checksum of the file in a SHA256SUMS file
+func findChecksum(sums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == name {
+			return fields[0], nil
+		}
+	}
+	return "", errors.Newf("no checksum found for %s, the platform may not be supported", name)
+}
+
+func download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "create request")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Newf("GET %s: unexpected status %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+
+	"github.com/sourcegraph/log"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var prefetchCommand = &cli.Command{
+	Name:  "prefetch",
+	Usage: "Fill a bundle with everything needed to generate the configs offline",
+	Description: `The configs are generated once into a temporary dir, and everything fetched on the way is added to the bundle:
+the terraform or OpenTofu binary, the provider plugins, the npm packages and the npm and deps.dev metadata.
+Only providers can be prefetched, modules are always downloaded from their source.
+The configs are generated one at a time because the plugin cache of terraform is not safe for concurrent use.`,
+	Flags: []cli.Flag{
+		configFlag,
+		defaultsFlag,
+		setFlag,
+		cdktfVersionFlag,
+		toolchainFlag,
+		terraformVersionFlag,
+		openTofuVersionFlag,
+		registryURLFlag,
+		credentialsFileFlag,
+		bundleFlag,
+	},
+	UsageText: `
+# Fill a bundle with everything needed to generate the google provider
+cdktf-provider-gen prefetch -config google.yaml -bundle ./bundle
+
+# Generate the google provider without network access
+cdktf-provider-gen -config google.yaml -offline -bundle ./bundle
+    `,
+	Action: func(c *cli.Context) error {
+		b, err := newBundle(bundleFlag.Get(c), false)
+		if err != nil {
+			return err
+		}
+		if err := b.init(); err != nil {
+			return err
+		}
+		// the generated code is only a by-product, only the bundle is kept
+		workDir, err := os.MkdirTemp("", "cdktf-provider-gen-prefetch")
+		if err != nil {
+			return errors.Wrap(err, "create temp dir")
+		}
+		defer os.RemoveAll(workDir)
+		return generateConfigs(c, log.Scoped("prefetch"), workDir, b)
+	},
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/cdktf"
+)
+
+const (
+	// schemaFileName is the name of the provider schema file in the project dir
+	schemaFileName = "schema.json"
+	// generateFromSchemaScriptName is the name of the script generating the provider bindings
+	// from the schema file in the project dir
+	generateFromSchemaScriptName = "generate-from-schema.js"
+)
+
+// writeProviderSchema copies the provider schema file to the project dir along with the
+// script generating the bindings from it. The schema file must have the schema of the provider.
+func writeProviderSchema(path string, addr cdktf.ProviderAddress, projectDir string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "read provider schema file")
+	}
+	var schema struct {
+		ProviderSchemas map[string]json.RawMessage `json:"provider_schemas"`
+	}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		return errors.Wrapf(err, "parse provider schema file %q", path)
+	}
+	if _, ok := schema.ProviderSchemas[addr.String()]; !ok {
+		found := make([]string, 0, len(schema.ProviderSchemas))
+		for p := range schema.ProviderSchemas {
+			found = append(found, p)
+		}
+		sort.Strings(found)
+		return errors.Newf("provider %q not found in schema file %q, found: [%s]", addr, path, strings.Join(found, ", "))
+	}
+
+	if err := os.WriteFile(filepath.Join(projectDir, schemaFileName), b, 0644); err != nil {
+		return errors.Wrap(err, "write provider schema file")
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, generateFromSchemaScriptName), generateFromSchemaScript, 0644); err != nil {
+		return errors.Wrap(err, "write generate script")
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/generator"
+)
+
+// languageTarget is the packaging stage of a target language.
+// Everything before it, i.e., fetching and compiling the jsii project, is shared by every language.
+type languageTarget struct {
+	// Pacmak is the jsii-pacmak target, e.g., go
+	Pacmak string
+	// JsiiConfig is the `jsii.targets.<pacmak>` config of package.json, nil if the target has none
+	JsiiConfig any
+	// DistDir is the directory jsii-pacmak writes the package to, relative to the project dir
+	DistDir string
+	// Include are the glob patterns of the files in the dist dir to copy to the output dir,
+	// everything is copied if empty
+	Include []string
+	// OutputName is the name of the output directory under the config output
+	OutputName string
+	// Version is the version the package is published with if it differs from the version
+	// of the jsii project, it is only set while the package is built
+	Version string
+	// PostProcess is an optional step run on the dist dir before it is copied to the output dir
+	PostProcess func(ctx context.Context, distDir string) error
+}
+
+// languageTargets returns the packaging stage of every config target, version is the version
+// of the packages, and b is the bundle the packaging metadata is read from or added to, if any
+func languageTargets(config *generator.Config, version string, b *bundle) ([]languageTarget, error) {
+	targets := make([]languageTarget, 0, len(config.Target))
+	for _, target := range config.Target {
+		t, err := newLanguageTarget(config, target, version, b)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// newLanguageTarget returns the packaging stage of a single target
+func newLanguageTarget(config *generator.Config, target *generator.Target, version string, b *bundle) (languageTarget, error) {
+	switch {
+	case target.Go != nil:
+		t := target.Go
+		jsiiConfig := map[string]string{
+			"moduleName":  t.ModuleName,
+			"packageName": t.PackageName,
+		}
+		if t.VersionSuffix != "" {
+			jsiiConfig["versionSuffix"] = t.VersionSuffix
+		}
+		return languageTarget{
+			Pacmak:     "go",
+			JsiiConfig: jsiiConfig,
+			DistDir:    filepath.Join("dist", "go", t.PackageName),
+			OutputName: target.OutputName(),
+			PostProcess: func(ctx context.Context, distDir string) error {
+				if err := pinCdktfGoDependencies(ctx, b, config.CdktfVersion, filepath.Join(distDir, "go.mod")); err != nil {
+					return errors.Wrap(err, "pin cdktf go dependencies")
+				}
+				if err := setGoModulePath(distDir, goModulePath(t, version)); err != nil {
+					return errors.Wrap(err, "set go module path")
+				}
+				if t.GoMod != nil {
+					if err := applyGoMod(distDir, t.GoMod); err != nil {
+						return errors.Wrap(err, "apply go.mod directives")
+					}
+				}
+				if t.Split != nil {
+					split := *t.Split
+					if split.Version == "" {
+						split.Version = goModuleVersion(t, version)
+					}
+					if err := splitGoModules(distDir, &split); err != nil {
+						return errors.Wrap(err, "split go modules")
+					}
+				}
+				return nil
+			},
+		}, nil
+
+	case target.Python != nil:
+		t := target.Python
+		return languageTarget{
+			Pacmak: "python",
+			JsiiConfig: map[string]string{
+				"distName": t.DistName,
+				"module":   t.Module,
+			},
+			// the sdist and wheel are written to dist/python
+			DistDir:    filepath.Join("dist", "python"),
+			OutputName: target.OutputName(),
+		}, nil
+
+	case target.TypeScript != nil:
+		return languageTarget{
+			// the npm package is already built by the compile step,
+			// jsii-pacmak only needs to `npm pack` it to dist/js
+			Pacmak:     "js",
+			DistDir:    filepath.Join("dist", "js"),
+			OutputName: target.OutputName(),
+			Version:    version,
+		}, nil
+
+	case target.Java != nil:
+		t := target.Java
+		return languageTarget{
+			Pacmak: "java",
+			JsiiConfig: map[string]any{
+				"package": t.Package,
+				"maven": map[string]string{
+					"groupId":    t.GroupID,
+					"artifactId": t.ArtifactID,
+				},
+			},
+			// dist/java is a local maven repository with the jar, sources and pom
+			DistDir:    filepath.Join("dist", "java"),
+			OutputName: target.OutputName(),
+		}, nil
+
+	case target.CSharp != nil:
+		t := target.CSharp
+		return languageTarget{
+			Pacmak: "dotnet",
+			JsiiConfig: map[string]string{
+				"namespace": t.Namespace,
+				"packageId": t.PackageID,
+			},
+			DistDir: filepath.Join("dist", "dotnet"),
+			// only keep the packages, dist/dotnet also has the generated sources
+			Include:    []string{"*.nupkg", "*.snupkg"},
+			OutputName: target.OutputName(),
+		}, nil
+	}
+	return languageTarget{}, errors.Newf("unsupported target language %q", target.Language())
+}
+
+// skipExcluded returns a copy option skipping the entries of the dist dir not matching any of the include patterns.
+// The patterns only match files directly in the dist dir, sub-directories are always skipped.
+func skipExcluded(include []string) func(info os.FileInfo, src, dest string) (bool, error) {
+	return func(info os.FileInfo, src, dest string) (bool, error) {
+		if len(include) == 0 {
+			return false, nil
+		}
+		if info.IsDir() {
+			return true, nil
+		}
+		for _, pattern := range include {
+			if ok, err := filepath.Match(pattern, info.Name()); err != nil || ok {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+}
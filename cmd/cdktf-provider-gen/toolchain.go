@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	hcversion "github.com/hashicorp/go-version"
+	hcfs "github.com/hashicorp/hc-install/fs"
+	hcproduct "github.com/hashicorp/hc-install/product"
+	tfreleases "github.com/hashicorp/hc-install/releases"
+	"github.com/sourcegraph/log"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/generator"
+)
+
+// toolchain is the versions of the tools a config is generated with
+type toolchain struct {
+	CdktfVersion string
+	// Terraform is the binary exposed to cdktf as terraform,
+	// generator.ToolchainTerraform or generator.ToolchainOpenTofu
+	Terraform        string
+	TerraformVersion string
+}
+
+// toolchainDefaults are the toolchain of configs without one
+type toolchainDefaults struct {
+	CdktfVersion     string
+	Terraform        string
+	TerraformVersion string
+	OpenTofuVersion  string
+}
+
+// toolchainGroup are the configs generated with the same toolchain
+type toolchainGroup struct {
+	Toolchain toolchain
+	Configs   []*generator.Config
+}
+
+// groupByToolchain fills in the default toolchain of configs without one, and groups
+// the configs by toolchain in the order of the first config of every group.
+func groupByToolchain(configs []*generator.Config, defaults toolchainDefaults) []toolchainGroup {
+	var groups []toolchainGroup
+	index := make(map[toolchain]int)
+	for _, config := range configs {
+		if config.CdktfVersion == "" {
+			config.CdktfVersion = defaults.CdktfVersion
+		}
+		if config.Toolchain == "" {
+			config.Toolchain = defaults.Terraform
+		}
+		if config.TerraformVersion == "" {
+			config.TerraformVersion = defaults.TerraformVersion
+			if config.Toolchain == generator.ToolchainOpenTofu {
+				config.TerraformVersion = defaults.OpenTofuVersion
+			}
+		}
+		tc := toolchain{
+			CdktfVersion:     config.CdktfVersion,
+			Terraform:        config.Toolchain,
+			TerraformVersion: config.TerraformVersion,
+		}
+		i, ok := index[tc]
+		if !ok {
+			i = len(groups)
+			index[tc] = i
+			groups = append(groups, toolchainGroup{Toolchain: tc})
+		}
+		groups[i].Configs = append(groups[i].Configs, config)
+	}
+	return groups
+}
+
+// generateGroup sets up the terraform of the toolchain group and generates its configs.
+// If terraform can't be set up, every config of the group fails. Terraform is not set up
+// if every config is generated from a provider schema file.
+func generateGroup(ctx context.Context, logger log.Logger, group toolchainGroup, tf *terraformResolver, opts generateOptions, concurrency int) generateSummary {
+	if !needsTerraform(group.Configs) {
+		logger.Debug("skipping terraform setup, every config is generated from a schema file")
+		return generateAll(ctx, logger, group.Configs, opts, concurrency)
+	}
+	binary, err := tf.Resolve(ctx, logger, group.Toolchain.Terraform, group.Toolchain.TerraformVersion)
+	if err != nil {
+		logger.Error("failed to set up terraform", log.Error(err))
+		return failGroup(group, err)
+	}
+
+	// workarounad for lack of well supported terraform toolchains for bazel
+	// so we need to bring our own terraform and configure it in the path
+	// so the cdktf-cli npm package can access it.
+	// The PATH is only set on the commands we run, every job of the group shares the same binary.
+	// The binary is linked as terraform, which is the only name cdktf looks up, e.g., for OpenTofu.
+	binDir, err := os.MkdirTemp("", "tf-bin")
+	if err != nil {
+		return failGroup(group, errors.Wrap(err, "create temp tf-bin dir"))
+	}
+	defer os.RemoveAll(binDir)
+	if err := os.Symlink(binary, filepath.Join(binDir, hcproduct.Terraform.BinaryName())); err != nil {
+		return failGroup(group, errors.Wrap(err, "link terraform binary"))
+	}
+
+	opts.Environ = environWithPath(binDir)
+	return generateAll(ctx, logger, group.Configs, opts, concurrency)
+}
+
+// needsTerraform returns true if any of the configs is generated with terraform,
+// i.e., not from a provider schema file
+func needsTerraform(configs []*generator.Config) bool {
+	for _, config := range configs {
+		if config.Provider == nil || config.Provider.SchemaFile == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// failGroup returns the summary of a group whose configs all failed with err
+func failGroup(group toolchainGroup, err error) generateSummary {
+	summary := make(generateSummary, len(group.Configs))
+	for i, config := range group.Configs {
+		summary[i] = generateResult{Name: config.Name, Err: err}
+	}
+	return summary
+}
+
+// terraformResolver finds or installs the binary exposed to cdktf as terraform,
+// i.e., terraform or OpenTofu
+type terraformResolver struct {
+	// Binary is the path of the binary to use instead of installing it,
+	// its version must be the requested version
+	Binary string
+	// FromPath uses the binary on PATH if it is the requested version
+	FromPath bool
+	// CacheDir is the directory binaries are installed in, in a sub-directory per product and version
+	CacheDir string
+	// Offline never installs binaries, they must be in the cache dir already
+	Offline bool
+}
+
+// Resolve returns the absolute path of the binary of the toolchain and version.
+// Unless a binary is given or found on PATH, it is installed in the cache dir
+// if it is not already cached.
+func (r *terraformResolver) Resolve(ctx context.Context, logger log.Logger, toolchain, version string) (string, error) {
+	v, err := hcversion.NewVersion(version)
+	if err != nil {
+		return "", errors.Wrapf(err, "parse %s version %q", toolchain, version)
+	}
+	product, install := hcproduct.Terraform, installTerraform
+	if toolchain == generator.ToolchainOpenTofu {
+		product, install = openTofu, installOpenTofu
+	}
+	logger = logger.With(log.String("product", product.Name))
+
+	if r.Binary != "" {
+		binary, err := filepath.Abs(r.Binary)
+		if err != nil {
+			return "", errors.Wrapf(err, "resolve terraform binary %q", r.Binary)
+		}
+		actual, err := product.GetVersion(ctx, binary)
+		if err != nil {
+			return "", errors.Wrapf(err, "get version of %s binary %q", product.Name, binary)
+		}
+		if !actual.Equal(v) {
+			return "", errors.Newf("%s binary %q is version %s, but %s is required", product.Name, binary, actual, v)
+		}
+		return binary, nil
+	}
+
+	if r.FromPath {
+		finder := &hcfs.ExactVersion{Product: product, Version: v}
+		binary, err := finder.Find(ctx)
+		if err == nil {
+			logger.Debug("using binary from PATH", log.String("binary", binary))
+			return binary, nil
+		}
+		logger.Debug("no matching binary on PATH", log.Error(err))
+	}
+
+	productDir := filepath.Join(r.CacheDir, product.Name)
+	installDir := filepath.Join(productDir, v.String())
+	binary := filepath.Join(installDir, product.BinaryName())
+	if _, err := os.Stat(binary); err == nil {
+		logger.Debug("using cached binary", log.String("binary", binary))
+		return binary, nil
+	}
+	if r.Offline {
+		return "", errors.Newf("%s %s is not in %q, run prefetch to add it", product.Name, v, r.CacheDir)
+	}
+
+	logger.Info("installing", log.String("dir", installDir))
+	if err := os.MkdirAll(productDir, 0755); err != nil {
+		return "", errors.Wrap(err, "create toolchain cache dir")
+	}
+	// install into a temp dir first so that an interrupted install is never used
+	tmpDir, err := os.MkdirTemp(productDir, ".install-"+v.String()+"-")
+	if err != nil {
+		return "", errors.Wrap(err, "create install dir")
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := install(ctx, v, tmpDir); err != nil {
+		return "", errors.Wrapf(err, "install %s %s", product.Name, v)
+	}
+	if err := os.Rename(tmpDir, installDir); err != nil {
+		// another run may have installed the same version in the meantime
+		if _, statErr := os.Stat(binary); statErr != nil {
+			return "", errors.Wrap(err, "move binary to cache dir")
+		}
+	}
+	return binary, nil
+}
+
+// installTerraform installs the terraform release of the version into dir
+func installTerraform(ctx context.Context, version *hcversion.Version, dir string) error {
+	installer := &tfreleases.ExactVersion{
+		Product:    hcproduct.Terraform,
+		Version:    version,
+		InstallDir: dir,
+	}
+	_, err := installer.Install(ctx)
+	return err
+}
+
+// defaultToolchainCacheDir returns the directory terraform and OpenTofu are cached in by default
+func defaultToolchainCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "get user cache dir, use -toolchain-cache-dir instead")
+	}
+	return filepath.Join(dir, "cdktf-provider-gen", "toolchains"), nil
+}
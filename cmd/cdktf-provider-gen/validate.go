@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+	"github.com/urfave/cli/v2"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/generator"
+)
+
+var validateCommand = &cli.Command{
+	Name:      "validate",
+	Usage:     "Validate config files without generating any code",
+	ArgsUsage: "[config files...]",
+	Flags: []cli.Flag{
+		schemaFlag,
+		defaultsFlag,
+		setFlag,
+	},
+	UsageText: `
+# Validate config files
+cdktf-provider-gen validate google.yaml aws.yaml
+
+# Validate a config file with a value overridden
+cdktf-provider-gen validate -set provider.version=4.70.0 google.yaml
+
+# Print the JSON Schema of the config file format
+cdktf-provider-gen validate -schema > cdktf-provider-gen.schema.json
+    `,
+	Action: func(c *cli.Context) error {
+		if schemaFlag.Get(c) {
+			b, err := json.MarshalIndent(generator.JSONSchema(), "", "  ")
+			if err != nil {
+				return errors.Wrap(err, "marshal json schema")
+			}
+			_, err = fmt.Println(string(b))
+			return err
+		}
+
+		if c.NArg() == 0 {
+			return errors.New("at least one config file is required")
+		}
+		opts, err := loadOptions(c)
+		if err != nil {
+			return err
+		}
+		var failed int
+		for _, path := range c.Args().Slice() {
+			configs, err := generator.LoadConfigs(path, opts)
+			if err != nil {
+				failed++
+				fmt.Printf("FAIL  %s: %s\n", path, err)
+				continue
+			}
+			fmt.Printf("OK    %s (%d configs)\n", path, len(configs))
+		}
+		if failed > 0 {
+			return errors.Newf("%d of %d config files are invalid", failed, c.NArg())
+		}
+		return nil
+	},
+}
+
+// loadOptions returns the options to load config files with from the flags
+func loadOptions(c *cli.Context) (generator.LoadOptions, error) {
+	opts := generator.LoadOptions{
+		DefaultsFile: defaultsFlag.Get(c),
+		Set:          make(map[string]string),
+	}
+	for _, s := range setFlag.Get(c) {
+		path, value, ok := strings.Cut(s, "=")
+		if !ok || path == "" {
+			return opts, errors.Newf("invalid -set %q: must be in the form of path=value", s)
+		}
+		opts.Set[path] = value
+	}
+	return opts, nil
+}
@@ -31,10 +31,17 @@ func InitLogs(name string, buildCommit string) *log.PostInitCallbacks {
 
 }
 
-// LogCommands logs unredacted command args to the provided logger
-func LogCommands(ctx context.Context, logger log.Logger) context.Context {
+// LogCommands logs command args to the provided logger, with every secret redacted
+func LogCommands(ctx context.Context, logger log.Logger, secrets ...string) context.Context {
+	var pairs []string
+	for _, s := range secrets {
+		if s != "" {
+			pairs = append(pairs, s, "REDACTED")
+		}
+	}
+	redactor := strings.NewReplacer(pairs...)
 	return run.LogCommands(ctx, func(command run.ExecutedCommand) {
-		logger.Debug("running", log.String("cmd", strings.Join(command.Args, " ")))
+		logger.Debug("running", log.String("cmd", redactor.Replace(strings.Join(command.Args, " "))))
 	})
 }
 
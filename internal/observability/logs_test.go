@@ -0,0 +1,21 @@
+package observability
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sourcegraph/log/logtest"
+	"github.com/sourcegraph/run"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogCommands(t *testing.T) {
+	logger, exportLogs := logtest.Captured(t)
+	ctx := LogCommands(context.Background(), logger, "secret-token", "")
+
+	require.NoError(t, run.Cmd(ctx, "echo", "token=secret-token").Run().Wait())
+
+	logs := exportLogs()
+	require.Len(t, logs, 1)
+	require.Equal(t, "echo token=REDACTED", logs[0].Fields["cmd"])
+}
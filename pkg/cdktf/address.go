@@ -0,0 +1,234 @@
+package cdktf
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+const (
+	// DefaultRegistryHost is the registry used when a provider source has no hostname
+	DefaultRegistryHost = "registry.terraform.io"
+	// OpenTofuRegistryHost is the registry OpenTofu uses when a source has no hostname
+	OpenTofuRegistryHost = "registry.opentofu.org"
+	// DefaultProviderNamespace is the namespace used when a provider source only has a type
+	DefaultProviderNamespace = "hashicorp"
+)
+
+// ProviderAddress is a parsed provider source address,
+// e.g., registry.terraform.io/hashicorp/google
+type ProviderAddress struct {
+	Hostname  string
+	Namespace string
+	Type      string
+}
+
+var (
+	// addressPartPattern matches a namespace or type of a provider source address
+	addressPartPattern = regexp.MustCompile(`^[0-9A-Za-z](?:[0-9A-Za-z_-]{0,62}[0-9A-Za-z])?$`)
+	// hostnameLabelPattern matches a single label of a registry hostname
+	hostnameLabelPattern = regexp.MustCompile(`^[0-9a-z](?:[0-9a-z-]{0,61}[0-9a-z])?$`)
+)
+
+// ParseProviderAddress parses a provider source address in the form of
+// [<hostname>/]<namespace>/<type>, the same way terraform does for
+// `required_providers` sources.
+func ParseProviderAddress(source string) (ProviderAddress, error) {
+	return ParseProviderAddressWithHost(source, DefaultRegistryHost)
+}
+
+// ParseProviderAddressWithHost is ParseProviderAddress with another hostname
+// for sources without one, e.g., OpenTofuRegistryHost.
+func ParseProviderAddressWithHost(source, defaultHostname string) (ProviderAddress, error) {
+	if source == "" {
+		return ProviderAddress{}, errors.New("provider source is required")
+	}
+
+	addr := ProviderAddress{
+		Hostname:  defaultHostname,
+		Namespace: DefaultProviderNamespace,
+	}
+	parts := strings.Split(source, "/")
+	switch len(parts) {
+	case 1:
+		addr.Type = parts[0]
+	case 2:
+		addr.Namespace, addr.Type = parts[0], parts[1]
+	case 3:
+		addr.Hostname, addr.Namespace, addr.Type = parts[0], parts[1], parts[2]
+	default:
+		return ProviderAddress{}, errors.Newf("invalid provider source %q: must be in the form of [<hostname>/]<namespace>/<type>", source)
+	}
+
+	if err := validateHostname(addr.Hostname); err != nil {
+		return ProviderAddress{}, errors.Wrapf(err, "invalid provider source %q", source)
+	}
+	if !addressPartPattern.MatchString(addr.Namespace) {
+		return ProviderAddress{}, errors.Newf("invalid provider source %q: invalid namespace %q", source, addr.Namespace)
+	}
+	if !addressPartPattern.MatchString(addr.Type) {
+		return ProviderAddress{}, errors.Newf("invalid provider source %q: invalid type %q", source, addr.Type)
+	}
+	if name, ok := strings.CutPrefix(addr.Type, "terraform-provider-"); ok {
+		return ProviderAddress{}, errors.Newf("invalid provider source %q: type must not have the \"terraform-provider-\" prefix, did you mean %q?", source, name)
+	}
+	addr.Namespace = strings.ToLower(addr.Namespace)
+	addr.Type = strings.ToLower(addr.Type)
+	return addr, nil
+}
+
+func validateHostname(hostname string) error {
+	host, port, hasPort := strings.Cut(hostname, ":")
+	if hasPort {
+		if p, err := strconv.Atoi(port); err != nil || p <= 0 || p > 65535 {
+			return errors.Newf("invalid hostname %q: invalid port %q", hostname, port)
+		}
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return errors.Newf("invalid hostname %q: must be a fully qualified domain name", hostname)
+	}
+	for _, label := range labels {
+		if !hostnameLabelPattern.MatchString(label) {
+			return errors.Newf("invalid hostname %q", hostname)
+		}
+	}
+	return nil
+}
+
+func (a ProviderAddress) String() string {
+	return a.Hostname + "/" + a.Namespace + "/" + a.Type
+}
+
+// ModuleAddress is a parsed registry module source address,
+// e.g., terraform-google-modules/kubernetes-engine/google//modules/beta-private-cluster
+type ModuleAddress struct {
+	Hostname  string
+	Namespace string
+	Name      string
+	Provider  string
+	// Subdir is the optional sub-directory of the module package, e.g., modules/beta-private-cluster
+	Subdir string
+}
+
+// ParseModuleAddress parses a registry module source address in the form of
+// [<hostname>/]<namespace>/<name>/<provider>[//<subdir>]
+func ParseModuleAddress(source string) (ModuleAddress, error) {
+	return ParseModuleAddressWithHost(source, DefaultRegistryHost)
+}
+
+// ParseModuleAddressWithHost is ParseModuleAddress with another hostname
+// for sources without one, e.g., OpenTofuRegistryHost.
+func ParseModuleAddressWithHost(source, defaultHostname string) (ModuleAddress, error) {
+	pkg, subdir, _ := strings.Cut(source, "//")
+	addr := ModuleAddress{
+		Hostname: defaultHostname,
+		Subdir:   subdir,
+	}
+	parts := strings.Split(pkg, "/")
+	switch len(parts) {
+	case 3:
+		addr.Namespace, addr.Name, addr.Provider = parts[0], parts[1], parts[2]
+	case 4:
+		addr.Hostname, addr.Namespace, addr.Name, addr.Provider = parts[0], parts[1], parts[2], parts[3]
+	}
+	for _, part := range []string{addr.Namespace, addr.Name, addr.Provider} {
+		if !addressPartPattern.MatchString(part) {
+			return ModuleAddress{}, errors.Newf("invalid module source %q: must be in the form of [<hostname>/]<namespace>/<name>/<provider>[//<subdir>]", source)
+		}
+	}
+	if err := validateHostname(addr.Hostname); err != nil {
+		return ModuleAddress{}, errors.Wrapf(err, "invalid module source %q", source)
+	}
+	return addr, nil
+}
+
+// Package returns the address of the module package without the sub-directory
+func (a ModuleAddress) Package() string {
+	return a.Hostname + "/" + a.Namespace + "/" + a.Name + "/" + a.Provider
+}
+
+// ModuleSourceType is the type of a module source address
+// https://developer.hashicorp.com/terraform/language/modules/sources
+type ModuleSourceType string
+
+const (
+	ModuleSourceRegistry ModuleSourceType = "registry"
+	// ModuleSourceLocal is a local path starting with ./ or ../
+	ModuleSourceLocal ModuleSourceType = "local"
+	// ModuleSourceGit is a git repository, e.g., git::https://example.com/vpc.git?ref=v1.2.0
+	ModuleSourceGit ModuleSourceType = "git"
+	// ModuleSourceHTTP is an http(s) url, e.g., of a module archive
+	ModuleSourceHTTP ModuleSourceType = "http"
+)
+
+// ParseModuleSourceType returns the type of the module source the same way terraform detects it.
+// Local paths start with ./ or ../, git sources have the git:: prefix or are github.com,
+// bitbucket.org or git@ shorthands, and http sources are http(s) urls.
+// Anything else must be a registry module address.
+func ParseModuleSourceType(source string) (ModuleSourceType, error) {
+	switch {
+	case source == "":
+		return "", errors.New("module source is required")
+	case strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../"):
+		return ModuleSourceLocal, nil
+	case strings.HasPrefix(source, "git::") || strings.HasPrefix(source, "git@") ||
+		strings.HasPrefix(source, "github.com/") || strings.HasPrefix(source, "bitbucket.org/"):
+		return ModuleSourceGit, nil
+	case strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://"):
+		return ModuleSourceHTTP, nil
+	case strings.Contains(source, "::"):
+		return "", errors.Newf("unsupported module source %q: must be a registry, git, http or local source", source)
+	}
+	if _, err := ParseModuleAddress(source); err != nil {
+		return "", err
+	}
+	return ModuleSourceRegistry, nil
+}
+
+// ModuleSourceRef returns the value of the ref argument of a git module source,
+// e.g., v1.2.0 of git::https://example.com/vpc.git?ref=v1.2.0, or an empty string if there is none
+func ModuleSourceRef(source string) string {
+	_, query, ok := strings.Cut(source, "?")
+	if !ok {
+		return ""
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return ""
+	}
+	return values.Get("ref")
+}
+
+// ModuleSourceName returns the name of the module of the source, i.e., the last element of its
+// sub-directory or path, or the name of a registry module without a sub-directory,
+// e.g., beta-private-cluster of terraform-google-modules/kubernetes-engine/google//modules/beta-private-cluster
+func ModuleSourceName(source string) string {
+	if addr, err := ParseModuleAddress(source); err == nil && addr.Subdir == "" {
+		return addr.Name
+	}
+	source, _, _ = strings.Cut(source, "?")
+	if _, rest, ok := strings.Cut(source, "::"); ok {
+		source = rest
+	}
+	if _, rest, ok := strings.Cut(source, "://"); ok {
+		source = rest
+	}
+	if strings.HasPrefix(source, "git@") {
+		// scp-like git source, e.g., git@github.com:org/repo.git
+		_, source, _ = strings.Cut(source, ":")
+	}
+	var name string
+	for _, part := range strings.Split(source, "/") {
+		if part != "" && part != "." && part != ".." {
+			name = part
+		}
+	}
+	for _, ext := range []string{".git", ".zip", ".tar.gz", ".tgz"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
@@ -0,0 +1,185 @@
+package cdktf
+
+import (
+	"testing"
+
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProviderAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		host    string
+		want    autogold.Value
+		wantErr autogold.Value
+	}{
+		{
+			name:   "full address",
+			source: "registry.terraform.io/hashicorp/google",
+			want: autogold.Expect(ProviderAddress{
+				Hostname: "registry.terraform.io", Namespace: "hashicorp",
+				Type: "google",
+			}),
+		},
+		{
+			name:   "implied hostname",
+			source: "cloudflare/cloudflare",
+			want: autogold.Expect(ProviderAddress{
+				Hostname: "registry.terraform.io", Namespace: "cloudflare",
+				Type: "cloudflare",
+			}),
+		},
+		{
+			name:   "implied namespace",
+			source: "google-beta",
+			want: autogold.Expect(ProviderAddress{
+				Hostname: "registry.terraform.io", Namespace: "hashicorp",
+				Type: "google-beta",
+			}),
+		},
+		{
+			name:   "implied opentofu hostname",
+			source: "hashicorp/google",
+			host:   OpenTofuRegistryHost,
+			want: autogold.Expect(ProviderAddress{
+				Hostname: "registry.opentofu.org", Namespace: "hashicorp",
+				Type: "google",
+			}),
+		},
+		{
+			name:   "explicit hostname with opentofu",
+			source: "registry.terraform.io/hashicorp/google",
+			host:   OpenTofuRegistryHost,
+			want: autogold.Expect(ProviderAddress{
+				Hostname: "registry.terraform.io", Namespace: "hashicorp",
+				Type: "google",
+			}),
+		},
+		{
+			name:   "private registry with port",
+			source: "terraform.example.com:8443/Example/internal",
+			want: autogold.Expect(ProviderAddress{
+				Hostname: "terraform.example.com:8443", Namespace: "example",
+				Type: "internal",
+			}),
+		},
+		{
+			name:    "too many parts",
+			source:  "registry.terraform.io/hashicorp/google/extra",
+			wantErr: autogold.Expect(`invalid provider source "registry.terraform.io/hashicorp/google/extra": must be in the form of [<hostname>/]<namespace>/<type>`),
+		},
+		{
+			name:    "invalid hostname",
+			source:  "localhost/hashicorp/google",
+			wantErr: autogold.Expect(`invalid provider source "localhost/hashicorp/google": invalid hostname "localhost": must be a fully qualified domain name`),
+		},
+		{
+			name:    "invalid type",
+			source:  "hashicorp/goo gle",
+			wantErr: autogold.Expect(`invalid provider source "hashicorp/goo gle": invalid type "goo gle"`),
+		},
+		{
+			name:    "type with provider prefix",
+			source:  "hashicorp/terraform-provider-google",
+			wantErr: autogold.Expect(`invalid provider source "hashicorp/terraform-provider-google": type must not have the "terraform-provider-" prefix, did you mean "google"?`),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseProviderAddress(tc.source)
+			if tc.host != "" {
+				got, err = ParseProviderAddressWithHost(tc.source, tc.host)
+			}
+			if tc.wantErr != nil {
+				require.Error(t, err)
+				tc.wantErr.Equal(t, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			tc.want.Equal(t, got)
+		})
+	}
+}
+
+func TestParseModuleAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		want    autogold.Value
+		wantErr autogold.Value
+	}{
+		{
+			name:   "with sub-directory",
+			source: "terraform-google-modules/kubernetes-engine/google//modules/beta-private-cluster",
+			want: autogold.Expect(ModuleAddress{
+				Hostname: "registry.terraform.io", Namespace: "terraform-google-modules",
+				Name:     "kubernetes-engine",
+				Provider: "google",
+				Subdir:   "modules/beta-private-cluster",
+			}),
+		},
+		{
+			name:   "private registry",
+			source: "app.terraform.io/example-corp/k8s-cluster/azurerm",
+			want: autogold.Expect(ModuleAddress{
+				Hostname: "app.terraform.io", Namespace: "example-corp",
+				Name:     "k8s-cluster",
+				Provider: "azurerm",
+			}),
+		},
+		{
+			name:    "not a registry module",
+			source:  "./modules/vpc",
+			wantErr: autogold.Expect(`invalid module source "./modules/vpc": must be in the form of [<hostname>/]<namespace>/<name>/<provider>[//<subdir>]`),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseModuleAddress(tc.source)
+			if tc.wantErr != nil {
+				require.Error(t, err)
+				tc.wantErr.Equal(t, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			tc.want.Equal(t, got)
+		})
+	}
+}
+
+func TestParseModuleSourceType(t *testing.T) {
+	tests := []struct {
+		source   string
+		want     autogold.Value
+		wantRef  autogold.Value
+		wantName autogold.Value
+		wantErr  autogold.Value
+	}{
+		{source: "terraform-aws-modules/vpc/aws", want: autogold.Expect(ModuleSourceRegistry), wantRef: autogold.Expect(""), wantName: autogold.Expect("vpc")},
+		{source: "./modules/vpc", want: autogold.Expect(ModuleSourceLocal), wantRef: autogold.Expect(""), wantName: autogold.Expect("vpc")},
+		{source: "terraform-google-modules/kubernetes-engine/google//modules/beta-private-cluster", want: autogold.Expect(ModuleSourceRegistry), wantRef: autogold.Expect(""), wantName: autogold.Expect("beta-private-cluster")},
+		{source: "../modules/vpc", want: autogold.Expect(ModuleSourceLocal), wantRef: autogold.Expect(""), wantName: autogold.Expect("vpc")},
+		{source: "git::https://example.com/vpc.git?ref=v1.2.0", want: autogold.Expect(ModuleSourceGit), wantRef: autogold.Expect("v1.2.0"), wantName: autogold.Expect("vpc")},
+		{source: "github.com/sourcegraph/terraform-modules//vpc?ref=main", want: autogold.Expect(ModuleSourceGit), wantRef: autogold.Expect("main"), wantName: autogold.Expect("vpc")},
+		{source: "git@github.com:sourcegraph/terraform-modules.git", want: autogold.Expect(ModuleSourceGit), wantRef: autogold.Expect(""), wantName: autogold.Expect("terraform-modules")},
+		{source: "https://example.com/vpc-module.zip", want: autogold.Expect(ModuleSourceHTTP), wantRef: autogold.Expect(""), wantName: autogold.Expect("vpc-module")},
+		{source: "s3::https://s3-eu-west-1.amazonaws.com/modules/vpc.zip", wantErr: autogold.Expect(`unsupported module source "s3::https://s3-eu-west-1.amazonaws.com/modules/vpc.zip": must be a registry, git, http or local source`)},
+		{source: "modules/vpc", wantErr: autogold.Expect(`invalid module source "modules/vpc": must be in the form of [<hostname>/]<namespace>/<name>/<provider>[//<subdir>]`)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.source, func(t *testing.T) {
+			got, err := ParseModuleSourceType(tc.source)
+			if tc.wantErr != nil {
+				require.Error(t, err)
+				tc.wantErr.Equal(t, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			tc.want.Equal(t, got)
+			tc.wantRef.Equal(t, ModuleSourceRef(tc.source))
+			tc.wantName.Equal(t, ModuleSourceName(tc.source))
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package cdktf
+
+import (
+	"path"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// ResourceFilter selects the resources and data sources of a provider by their
+// terraform type name, e.g., google_compute_instance, with glob patterns, see path.Match.
+// Patterns match resources and data sources alike.
+type ResourceFilter struct {
+	// Include are the patterns of the types to keep, every type is kept if empty.
+	// Patterns prefixed with ! exclude types instead, e.g., !google_*_iam_*
+	Include []string
+	// Exclude are the patterns of the types to drop, they take precedence over Include
+	Exclude []string
+}
+
+// IsEmpty returns true if the filter keeps every type
+func (f ResourceFilter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// Match returns true if the resource or data source type is kept by the filter
+func (f ResourceFilter) Match(typ string) bool {
+	included, hasIncludes := false, false
+	for _, pattern := range f.Include {
+		if negated, ok := strings.CutPrefix(pattern, "!"); ok {
+			if matchPattern(negated, typ) {
+				return false
+			}
+			continue
+		}
+		hasIncludes = true
+		included = included || matchPattern(pattern, typ)
+	}
+	for _, pattern := range f.Exclude {
+		if matchPattern(pattern, typ) {
+			return false
+		}
+	}
+	return included || !hasIncludes
+}
+
+func matchPattern(pattern, typ string) bool {
+	ok, _ := path.Match(pattern, typ)
+	return ok
+}
+
+// ValidateResourcePattern returns an error if the pattern of a ResourceFilter is malformed
+func ValidateResourcePattern(pattern string) error {
+	glob := strings.TrimPrefix(pattern, "!")
+	if glob == "" {
+		return errors.New("pattern is empty")
+	}
+	if _, err := path.Match(glob, ""); err != nil {
+		return errors.Newf("%q is not a valid pattern", pattern)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package cdktf
+
+import (
+	"testing"
+
+	"github.com/hexops/autogold/v2"
+)
+
+func TestResourceFilter(t *testing.T) {
+	filter := ResourceFilter{
+		Include: []string{"google_compute_*", "google_storage_bucket*", "!google_*_iam_*"},
+		Exclude: []string{"google_compute_region_*"},
+	}
+	tests := []struct {
+		typ  string
+		want autogold.Value
+	}{
+		{typ: "google_compute_instance", want: autogold.Expect(true)},
+		{typ: "google_storage_bucket_object", want: autogold.Expect(true)},
+		{typ: "google_compute_instance_iam_member", want: autogold.Expect(false)},
+		{typ: "google_compute_region_disk", want: autogold.Expect(false)},
+		{typ: "google_sql_database", want: autogold.Expect(false)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.typ, func(t *testing.T) {
+			tc.want.Equal(t, filter.Match(tc.typ))
+		})
+	}
+
+	t.Run("only excludes", func(t *testing.T) {
+		filter := ResourceFilter{Include: []string{"!google_*_iam_*"}}
+		autogold.Expect([]bool{true, false}).Equal(t, []bool{
+			filter.Match("google_compute_instance"),
+			filter.Match("google_project_iam_member"),
+		})
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		autogold.Expect(true).Equal(t, ResourceFilter{}.Match("google_compute_instance"))
+	})
+}
+
+func TestValidateResourcePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr autogold.Value
+	}{
+		{pattern: "google_compute_*", wantErr: autogold.Expect("")},
+		{pattern: "!google_*_iam_*", wantErr: autogold.Expect("")},
+		{pattern: "!", wantErr: autogold.Expect("pattern is empty")},
+		{pattern: "google_[compute", wantErr: autogold.Expect(`"google_[compute" is not a valid pattern`)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			var got string
+			if err := ValidateResourcePattern(tc.pattern); err != nil {
+				got = err.Error()
+			}
+			tc.wantErr.Equal(t, got)
+		})
+	}
+}
@@ -14,12 +14,53 @@ type Source struct {
 	// Name is the name of the provider or module
 	// This field is only used internally to render the `cdktf.json` template
 	// It will be ignored in the incoming config file
-	Name string `json:"name,omitempty"`
+	Name string `json:"name,omitempty" jsonschema:"-"`
 
 	// Source is the target provider or module to generate
 	// e.g., registry.terraform.io/hashicorp/google
+	// Modules can also be a git, http or local source, e.g., ./modules/vpc,
+	// local paths are relative to the config file.
 	Source string `json:"source"`
 	// Version of the target provider or module to generate
-	// e.g., "3.19.0"
+	// e.g., "3.19.0", or a version constraint, e.g., "~> 3.19"
+	// Constraints are resolved to the newest matching version from the registry
+	// before generation. Only registry modules have versions, pin git sources with ?ref= instead.
 	Version string `json:"version,omitempty"`
+
+	// Binary is the path of a local provider binary to generate from instead of
+	// installing the provider from a registry, e.g., an unreleased in-house provider.
+	// Version is then required and must be the exact version of the binary.
+	// Only supported by providers.
+	Binary string `json:"binary,omitempty"`
+	// Mirror is the path of a terraform filesystem mirror directory to install the
+	// provider from instead of a registry, in the packed or unpacked layout.
+	// Only supported by providers.
+	Mirror string `json:"mirror,omitempty"`
+	// SchemaFile is the path of the provider schema exported with
+	// `terraform providers schema -json` to generate from without running terraform.
+	// Version is then required and must be the exact version the schema was exported from.
+	// Only supported by providers.
+	SchemaFile string `json:"schemaFile,omitempty"`
+
+	// Include are glob patterns of the resource and data source types to generate bindings for,
+	// e.g., google_compute_*, patterns prefixed with ! exclude types, e.g., !google_*_iam_*
+	// Every type is generated if empty. Only supported by providers.
+	Include []string `json:"include,omitempty"`
+	// Exclude are glob patterns of the resource and data source types not to generate bindings for,
+	// they take precedence over Include. Only supported by providers.
+	Exclude []string `json:"exclude,omitempty"`
+
+	// LocalPath is the path of a local module source resolved against the config file
+	// This field is only set internally when loading the config file
+	LocalPath string `json:"-"`
+}
+
+// Filter returns the filter of the resources and data sources to generate bindings for
+func (s *Source) Filter() ResourceFilter {
+	return ResourceFilter{Include: s.Include, Exclude: s.Exclude}
+}
+
+// IsLocal returns true if the provider is installed from a local binary or mirror
+func (s *Source) IsLocal() bool {
+	return s.Binary != "" || s.Mirror != ""
 }
@@ -0,0 +1,101 @@
+package generator
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Batch is a config file holding many configs to be generated in a single run
+type Batch struct {
+	// Defaults are merged into every entry of Configs.
+	// Values set on the entry take precedence over the defaults.
+	Defaults map[string]any `json:"defaults,omitempty"`
+
+	Configs []map[string]any `json:"configs"`
+}
+
+// NewConfigs parses a config file that may contain one or many configs.
+// The following formats are supported:
+//
+//   - a single config, same as NewConfig
+//   - a list of configs
+//   - a batch with a `configs` list and optional shared `defaults`
+//
+// Relative `extends` paths are resolved against the working directory, use
+// LoadConfigs to resolve them against the config file.
+func NewConfigs(b []byte) ([]*Config, error) {
+	return (&loader{dir: "."}).configs(b)
+}
+
+func (l *loader) configs(b []byte) ([]*Config, error) {
+	node, err := parseNode(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		entries  []*yaml.Node
+		defaults *yaml.Node
+		prefix   string
+	)
+	switch node.Kind {
+	case yaml.SequenceNode:
+		entries = node.Content
+	case yaml.MappingNode:
+		configs := mappingValue(node, "configs")
+		if configs == nil {
+			c, err := l.config(node, "")
+			if err != nil {
+				return nil, err
+			}
+			return []*Config{c}, nil
+		}
+		if errs := checkFields(node, reflect.TypeOf(Batch{}), ""); len(errs) > 0 {
+			return nil, errs
+		}
+		if configs.Kind != yaml.SequenceNode {
+			return nil, &FieldError{Path: "configs", Line: configs.Line, Err: errors.New("must be a list of configs")}
+		}
+		defaults = mappingValue(node, "defaults")
+		if errs := checkFields(defaults, reflect.TypeOf(Config{}), "defaults"); len(errs) > 0 {
+			return nil, errs
+		}
+		entries = configs.Content
+		prefix = "configs"
+	default:
+		return nil, errors.New("config file must be a config, a list of configs or a batch config")
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("batch config has no configs")
+	}
+
+	// report the errors of every entry at once
+	var errs FieldErrors
+	configs := make([]*Config, 0, len(entries))
+	for i, entry := range entries {
+		path := fmt.Sprintf("%s[%d]", prefix, i)
+		entry = copyNode(entry)
+		if entry.Kind != yaml.MappingNode {
+			errs = append(errs, &FieldError{Path: path, Line: entry.Line, Err: errors.New("must be a config")})
+			continue
+		}
+		mergeNodes(entry, defaults)
+		c, err := l.config(entry, path)
+		if entryErrs, ok := err.(FieldErrors); ok {
+			errs = append(errs, entryErrs...)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, &FieldError{Path: path, Err: err})
+			continue
+		}
+		configs = append(configs, c)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return configs, nil
+}
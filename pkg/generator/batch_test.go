@@ -0,0 +1,264 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/hexops/autogold/v2"
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/cdktf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfigs(t *testing.T) {
+	tests := []struct {
+		name    string
+		b       []byte
+		want    autogold.Value
+		wantErr autogold.Value
+	}{
+		{
+			name: "single config",
+			b: []byte(`
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+  version: 4.69.1
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`),
+			want: autogold.Expect([]*Config{
+				{
+					Name: "google", Provider: &cdktf.Source{
+						Source:  "registry.terraform.io/hashicorp/google",
+						Version: "4.69.1",
+					},
+					Target: Targets{{Go: &GoTarget{
+						Language:    "go",
+						ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
+						PackageName: "google",
+					}}},
+					Output: "gen",
+				},
+			}),
+		},
+		{
+			name: "list of configs",
+			b: []byte(`
+- name: google
+  provider:
+    source: registry.terraform.io/hashicorp/google
+    version: 4.69.1
+  target:
+    language: go
+    moduleName: github.com/sourcegraph/controller-cdktf/gen
+  output: gen
+- name: random
+  provider:
+    source: registry.terraform.io/hashicorp/random
+  target:
+    language: go
+    moduleName: github.com/sourcegraph/controller-cdktf/gen
+  output: gen
+`),
+			want: autogold.Expect([]*Config{
+				{
+					Name: "google", Provider: &cdktf.Source{
+						Source:  "registry.terraform.io/hashicorp/google",
+						Version: "4.69.1",
+					},
+					Target: Targets{{Go: &GoTarget{
+						Language:    "go",
+						ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
+						PackageName: "google",
+					}}},
+					Output: "gen",
+				},
+				{
+					Name: "random", Provider: &cdktf.Source{
+						Source: "registry.terraform.io/hashicorp/random",
+					},
+					Target: Targets{{Go: &GoTarget{
+						Language:    "go",
+						ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
+						PackageName: "random",
+					}}},
+					Output: "gen",
+				},
+			}),
+		},
+		{
+			name: "batch with defaults",
+			b: []byte(`
+defaults:
+  target:
+    language: go
+    moduleName: github.com/sourcegraph/controller-cdktf/gen
+  output: gen
+configs:
+  - name: google
+    provider:
+      source: registry.terraform.io/hashicorp/google
+      version: 4.69.1
+    target:
+      packageName: googleprovider
+  - name: gkeprivate
+    module:
+      source: terraform-google-modules/kubernetes-engine/google//modules/beta-private-cluster
+      version: "24.0.0"
+    output: modules
+`),
+			want: autogold.Expect([]*Config{
+				{
+					Name: "google", Provider: &cdktf.Source{
+						Source:  "registry.terraform.io/hashicorp/google",
+						Version: "4.69.1",
+					},
+					Target: Targets{{Go: &GoTarget{
+						Language:    "go",
+						ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
+						PackageName: "googleprovider",
+					}}},
+					Output: "gen",
+				},
+				{
+					Name: "gkeprivate", Module: Modules{{
+						Name:    "gkeprivate",
+						Source:  "terraform-google-modules/kubernetes-engine/google//modules/beta-private-cluster",
+						Version: "24.0.0",
+					}},
+					Target: Targets{{Go: &GoTarget{
+						Language:    "go",
+						ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
+						PackageName: "gkeprivate",
+					}}},
+					Output: "modules",
+				},
+			}),
+		},
+		{
+			name: "invalid: entry error",
+			b: []byte(`
+configs:
+  - name: google
+    provider:
+      source: registry.terraform.io/hashicorp/google
+    target:
+      language: go
+      moduleName: github.com/sourcegraph/controller-cdktf/gen
+  - name: random
+    provider:
+      source: registry.terraform.io/hashicorp/random
+`),
+			wantErr: autogold.Expect("line 9: configs[1].target: language target config is required"),
+		},
+		{
+			name: "invalid: unknown field in defaults",
+			b: []byte(`
+defaults:
+  target:
+    language: go
+    moduleName: github.com/sourcegraph/controller-cdktf/gen
+    packagename: google
+configs:
+  - name: google
+    provider:
+      source: registry.terraform.io/hashicorp/google
+`),
+			wantErr: autogold.Expect("line 6: defaults.target.packagename: unknown field"),
+		},
+		{
+			name: "invalid: entry error from defaults",
+			b: []byte(`
+defaults:
+  target:
+    language: go
+    moduleName: sourcegraph/controller-cdktf/gen
+configs:
+  - name: google
+    provider:
+      source: registry.terraform.io/hashicorp/google
+`),
+			wantErr: autogold.Expect(`line 5: configs[0].target.moduleName: malformed module path "sourcegraph/controller-cdktf/gen": missing dot in first path element`),
+		},
+		{
+			name: "invalid: errors of every entry",
+			b: []byte(`
+configs:
+  - name: google
+    provider:
+      source: registry.terraform.io/hashicorp/google
+  - name: aws
+    provider:
+      source: registry.terraform.io/hashicorp/aws
+    target:
+      language: go
+      moduleName: github.com/sourcegraph/controller-cdktf/gen
+  - random
+  - name: random
+    provider:
+      source: registry.terraform.io/hashicorp/random
+`),
+			wantErr: autogold.Expect(`line 3: configs[0].target: language target config is required
+line 12: configs[2]: must be a config
+line 13: configs[3].target: language target config is required`),
+		},
+		{
+			name:    "invalid: empty batch",
+			b:       []byte(`configs: []`),
+			wantErr: autogold.Expect("batch config has no configs"),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := NewConfigs(tc.b)
+			if tc.wantErr != nil {
+				require.Error(t, err)
+				tc.wantErr.Equal(t, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			tc.want.Equal(t, got)
+		})
+	}
+}
+
+func TestMergeNodes(t *testing.T) {
+	defaults, err := parseNode([]byte(`
+output: gen
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+`))
+	require.NoError(t, err)
+	entry, err := parseNode([]byte(`
+name: google
+target:
+  moduleName: github.com/sourcegraph/other/gen
+`))
+	require.NoError(t, err)
+
+	mergeNodes(entry, defaults)
+	var got map[string]any
+	require.NoError(t, entry.Decode(&got))
+	autogold.Expect(map[string]any{
+		"name":   "google",
+		"output": "gen",
+		"target": map[string]any{
+			"language":   "go",
+			"moduleName": "github.com/sourcegraph/other/gen",
+		},
+	}).Equal(t, got)
+
+	// merged values keep the lines of the defaults
+	lines := make(map[string]int)
+	nodeLines(entry, "", lines)
+	require.Equal(t, 4, lines["target.language"])
+
+	// merging must never modify the defaults
+	other, err := parseNode([]byte(`name: random`))
+	require.NoError(t, err)
+	mergeNodes(other, defaults)
+	mappingValue(other, "target").Content[1].Value = "python"
+	require.Equal(t, "go", mappingValue(defaults, "target").Content[1].Value)
+}
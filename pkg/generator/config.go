@@ -1,11 +1,15 @@
 package generator
 
 import (
+	"bytes"
 	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
 
 	"github.com/sourcegraph/cdktf-provider-gen/pkg/cdktf"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
-	"sigs.k8s.io/yaml"
+	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
@@ -13,53 +17,473 @@ type Config struct {
 	// This is used as the output go module suffix
 	Name string `json:"name"`
 
+	// Extends is the path of a partial config file deep merged into this config,
+	// relative to this config file. Values set on this config take precedence.
+	Extends string `json:"extends,omitempty"`
+
 	Provider *cdktf.Source `json:"provider"`
-	Module   *cdktf.Source `json:"module"`
+	// Module is either a single module or a list of modules in config files.
+	// Every module gets its own source file, and all of them are exported from the same package.
+	Module Modules `json:"module"`
+
+	// Target is the config of the target languages, either a single target or a list of
+	// targets in config files. Every target is packaged from the same compiled project.
+	Target Targets `json:"target"`
 
-	// Target is the config of the target language
-	Target *Target `json:"target"`
+	// CdktfVersion is the exact version of cdktf to generate the code with, e.g., 0.20.0
+	// If empty, defaults to the -cdktf-version flag.
+	CdktfVersion string `json:"cdktfVersion,omitempty"`
+	// Toolchain is the binary cdktf fetches the provider or module schema with,
+	// either terraform or opentofu. OpenTofu is exposed to cdktf as terraform.
+	// If empty, defaults to the -toolchain flag.
+	Toolchain string `json:"toolchain,omitempty"`
+	// TerraformVersion is the exact version of the toolchain binary, e.g., 1.8.5,
+	// i.e., the version of OpenTofu if the toolchain is opentofu.
+	// If empty, defaults to the -terraform-version or -opentofu-version flag.
+	TerraformVersion string `json:"terraformVersion,omitempty"`
+
+	// Credentials are the credentials of private registries by hostname, e.g., app.terraform.io
+	// Use environment variables to keep tokens out of config files, e.g., ${TFC_TOKEN}.
+	Credentials map[string]HostCredentials `json:"credentials,omitempty"`
 
 	// Output is the parent direcotry to write the generated code to.
-	// The final output directory will be <output>/<Target.Go.PackageName>
+	// It can be a template of the provider or module version, e.g., gen/v{{ .Major }}, see VersionData.
+	// The final output directory of every target will be <output>/<Target.Go.PackageName> for go,
+	// <output>/<Target.Python.DistName> for python,
+	// <output>/<Target.TypeScript.OutputName()> for typescript,
+	// <output>/<Target.Java.ArtifactID> for java, and
+	// <output>/<Target.CSharp.PackageID> for csharp.
 	Output string `json:"output"`
 }
 
+// HostCredentials are the credentials of a registry host
+type HostCredentials struct {
+	// Token is the API token sent to the registry
+	Token Secret `json:"token"`
+}
+
+// Secret is a sensitive config value, it is redacted when marshaled, e.g., in logs
+type Secret string
+
+func (Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"REDACTED"`), nil
+}
+
+const (
+	// ToolchainTerraform fetches schemas with terraform
+	ToolchainTerraform = "terraform"
+	// ToolchainOpenTofu fetches schemas with OpenTofu,
+	// provider and module sources without a hostname are from the OpenTofu registry
+	ToolchainOpenTofu = "opentofu"
+)
+
+// Target is the config of the target language, exactly one of the fields is set
+// depending on the `language` field of the config.
 type Target struct {
-	Go *GoTarget
+	Go         *GoTarget
+	Python     *PythonTarget
+	TypeScript *TypeScriptTarget
+	Java       *JavaTarget
+	CSharp     *CSharpTarget
 }
 
+// Targets are the target languages of a config, at most one per language
+type Targets []*Target
+
 type GoTarget struct {
-	// Language of the generated code. Only "go" is supported at the moment.
+	// Language of the generated code, always "go"
 	Language string `json:"language"`
 
 	// ModuleName is the root module name, e.g., github.com/sourcegraph/controller-cdktf/gen
 	ModuleName string `json:"moduleName"`
 	// PackagName is the output package under the provided module above, e.g., google
-	// If empty, defaults to the provider name.
-	// The final full package path will be <moduleName>/<packageName>
+	// If empty, defaults to the provider name without hyphens, e.g., googlebeta for google-beta.
+	// It can be a template of the provider or module version, e.g., google{{ .Major }}, see VersionData.
+	// The final full package path will be <moduleName>/<packageName>, with the major version suffix
+	// /v<major> for major versions 2 and above if MajorVersion or MajorVersionSuffix is set,
+	// e.g., <moduleName>/<packageName>/v5
+	PackageName string `json:"packageName"`
+	// MajorVersion is the major version of the module path suffix, e.g., 5 for /v5
+	// If empty, the module path has no suffix unless MajorVersionSuffix is set,
+	// and the module must be tagged with v0 or v1 versions.
+	MajorVersion int `json:"majorVersion,omitempty"`
+	// MajorVersionSuffix derives the major version of the module path suffix from the major
+	// version of the provider or module if MajorVersion is empty, e.g., /v5 for google 5.x
+	MajorVersionSuffix bool `json:"majorVersionSuffix,omitempty"`
+	// VersionSuffix is appended to the version of the module, e.g., -devpreview
+	VersionSuffix string `json:"versionSuffix,omitempty"`
+	// GoMod are directives applied to the go.mod file of the module, and of every split module
+	GoMod *GoMod `json:"goMod,omitempty"`
+	// Split puts the namespace packages of the generated code, e.g., computeinstance,
+	// into their own Go modules, e.g., for providers too big for a single module.
+	// If empty, everything is generated into a single module.
+	Split *GoSplit `json:"split,omitempty"`
+}
+
+// GoMod are the directives of the go.mod file of the generated module,
+// they are applied after the cdktf dependencies are pinned
+type GoMod struct {
+	// Go is the version of the go directive, e.g., 1.22
+	Go string `json:"go,omitempty"`
+	// Toolchain is the toolchain directive, e.g., go1.22.5
+	Toolchain string `json:"toolchain,omitempty"`
+	// Replace are replace directives, e.g., of a fork of github.com/aws/jsii-runtime-go
+	Replace []GoModReplace `json:"replace,omitempty"`
+	// Retract are retract directives of versions of the generated module
+	Retract []GoModRetract `json:"retract,omitempty"`
+	// Exclude are exclude directives of module versions
+	Exclude []GoModExclude `json:"exclude,omitempty"`
+}
+
+// GoModReplace is a replace directive
+type GoModReplace struct {
+	// Path is the module path to replace, e.g., github.com/aws/jsii-runtime-go
+	Path string `json:"path"`
+	// Version is the version to replace, every version is replaced if empty
+	Version string `json:"version,omitempty"`
+	// NewPath is the module path of the replacement, e.g., github.com/your-org/jsii-runtime-go,
+	// or a local directory relative to the generated module, e.g., ../jsii-runtime-go
+	NewPath string `json:"newPath"`
+	// NewVersion is the version of the replacement, required unless NewPath is a local directory
+	NewVersion string `json:"newVersion,omitempty"`
+}
+
+// GoModRetract is a retract directive of a single version or a range of versions
+type GoModRetract struct {
+	// Low is the retracted version, or the lowest version of the range, e.g., v4.69.0
+	Low string `json:"low"`
+	// High is the highest version of the range, only Low is retracted if empty
+	High string `json:"high,omitempty"`
+	// Rationale is the comment of the directive, e.g., why the versions are retracted
+	Rationale string `json:"rationale,omitempty"`
+}
+
+// GoModExclude is an exclude directive
+type GoModExclude struct {
+	// Path is the module path, e.g., github.com/aws/jsii-runtime-go
+	Path string `json:"path"`
+	// Version is the excluded version, e.g., v1.98.0
+	Version string `json:"version"`
+}
+
+// GoSplit is the config of splitting the generated Go code into multiple modules.
+// The core module <moduleName>/<packageName> keeps the provider construct and the embedded
+// jsii runtime assets, and every namespace package gets its own module
+// <moduleName>/<packageName>/<namespace>, unless it is in a group.
+type GoSplit struct {
+	// Version is the version of the core module the other modules require, i.e., the version
+	// the core module is tagged with, e.g., v4.69.1. Its major version must match the
+	// major version suffix of the module. If empty, defaults to the version of the package if
+	// MajorVersion or MajorVersionSuffix of the target is set, and to v0.0.0 otherwise.
+	// The other modules also replace the core module with its directory, so that
+	// they build from the output before it is tagged.
+	Version string `json:"version,omitempty"`
+	// Groups put the namespace packages matching their patterns into a shared module
+	// instead, the first matching group of a package wins.
+	Groups []GoModuleGroup `json:"groups,omitempty"`
+}
+
+// CoreGoPackages are the packages of the generated Go code that stay in the core module
+// of a split, i.e., the embedded jsii runtime assets and the provider construct
+var CoreGoPackages = map[string]bool{"jsii": true, "provider": true, "internal": true}
+
+// GoModuleGroup is a module of namespace packages
+type GoModuleGroup struct {
+	// Name is the directory of the module under the core module, e.g., compute
+	// The module is <moduleName>/<packageName>/<name>, and its packages are
+	// <moduleName>/<packageName>/<name>/<namespace>.
+	Name string `json:"name"`
+	// Packages are the glob patterns of the namespace packages of the module, e.g., compute*
+	Packages []string `json:"packages"`
+}
+
+type PythonTarget struct {
+	// Language of the generated code, always "python"
+	Language string `json:"language"`
+
+	// DistName is the name of the python distribution, e.g., cdktf-provider-google
+	// If empty, defaults to cdktf-provider-<name>.
+	DistName string `json:"distName"`
+	// Module is the name of the python module to import, e.g., cdktf_provider_google
+	// If empty, defaults to the distribution name with hyphens replaced by underscores.
+	Module string `json:"module"`
+}
+
+type TypeScriptTarget struct {
+	// Language of the generated code, always "typescript"
+	Language string `json:"language"`
+
+	// PackageName is the name of the npm package without the scope, e.g., provider-google
+	// If empty, defaults to provider-<name>.
 	PackageName string `json:"packageName"`
+	// Scope is the optional npm scope of the package without the leading "@", e.g., your-org
+	// If both the scope and package name are empty, defaults to cdktf.
+	Scope string `json:"scope"`
+}
+
+type JavaTarget struct {
+	// Language of the generated code, always "java"
+	Language string `json:"language"`
+
+	// GroupID is the maven groupId of the artifact, e.g., com.your-org
+	GroupID string `json:"groupId"`
+	// ArtifactID is the maven artifactId, e.g., cdktf-provider-google
+	// If empty, defaults to cdktf-provider-<name>.
+	ArtifactID string `json:"artifactId"`
+	// Package is the java package of the generated code, e.g., com.yourorg.cdktf.providers.google
+	// If empty, defaults to <groupId>.<name> with hyphens replaced by underscores.
+	Package string `json:"package"`
+}
+
+type CSharpTarget struct {
+	// Language of the generated code, always "csharp"
+	Language string `json:"language"`
+
+	// Namespace is the .NET namespace of the generated code, e.g., YourOrg.Cdktf.Providers.Google
+	// If empty, defaults to the package id.
+	Namespace string `json:"namespace"`
+	// PackageID is the id of the NuGet package, e.g., YourOrg.Cdktf.Providers.Google
+	// If empty, defaults to Cdktf.Providers.<Name> with the name in pascal case.
+	PackageID string `json:"packageId"`
 }
 
+// RegistryHost returns the registry hostname of provider and module sources without one
+func (c *Config) RegistryHost() string {
+	if c.Toolchain == ToolchainOpenTofu {
+		return cdktf.OpenTofuRegistryHost
+	}
+	return cdktf.DefaultRegistryHost
+}
+
+// NpmName returns the full name of the npm package, e.g., @your-org/provider-google
+func (t *TypeScriptTarget) NpmName() string {
+	if t.Scope == "" {
+		return t.PackageName
+	}
+	return "@" + t.Scope + "/" + t.PackageName
+}
+
+// OutputName returns the name of the output directory of the package, e.g., your-org-provider-google
+func (t *TypeScriptTarget) OutputName() string {
+	if t.Scope == "" {
+		return t.PackageName
+	}
+	return t.Scope + "-" + t.PackageName
+}
+
+// OutputName returns the name of the output directory of the target under the config output
+func (t *Target) OutputName() string {
+	switch {
+	case t.Go != nil:
+		return t.Go.PackageName
+	case t.Python != nil:
+		return t.Python.DistName
+	case t.TypeScript != nil:
+		return t.TypeScript.OutputName()
+	case t.Java != nil:
+		return t.Java.ArtifactID
+	case t.CSharp != nil:
+		return t.CSharp.PackageID
+	}
+	return ""
+}
+
+// OutputDirs returns the output directory of every target of the config
+func (c *Config) OutputDirs() []string {
+	dirs := make([]string, 0, len(c.Target))
+	for _, t := range c.Target {
+		dirs = append(dirs, filepath.Join(c.Output, t.OutputName()))
+	}
+	return dirs
+}
+
+// NewConfig parses and validates a single config.
+// Unknown fields are rejected, and every error reports the path and line of the field.
+// Relative `extends` paths are resolved against the working directory.
 func NewConfig(b []byte) (*Config, error) {
-	var c Config
-	if err := yaml.Unmarshal(b, &c); err != nil {
-		return nil, errors.Wrap(err, "unmarshal config file")
+	node, err := parseNode(b)
+	if err != nil {
+		return nil, err
 	}
-	if c.Provider != nil && c.Module != nil {
-		return nil, errors.New("provider and module can't be set at the same time")
+	return (&loader{dir: "."}).config(node, "")
+}
+
+// newConfig decodes and validates the config node, path is the path of the
+// config node in the config file and is used to prefix errors.
+func newConfig(node *yaml.Node, path string) (*Config, error) {
+	if errs := checkFields(node, reflect.TypeOf(Config{}), path); len(errs) > 0 {
+		return nil, errs
 	}
-	if c.Target == nil {
-		return nil, errors.New("language target config is required")
+
+	var c Config
+	if err := decodeNode(node, &c); err != nil {
+		return nil, err
 	}
-	if c.Target.Go == nil {
-		return nil, errors.New("go target config is required")
+	for _, t := range c.Target {
+		if t != nil {
+			t.setDefaults(c.Name)
+		}
 	}
-	if c.Target.Go.PackageName == "" {
-		c.Target.Go.PackageName = c.Name
+	c.Module.setNames(c.Name)
+
+	if errs := c.validate(); len(errs) > 0 {
+		lines := make(map[string]int)
+		nodeLines(node, path, lines)
+		return nil, errs.withPrefix(path).withLines(lines)
 	}
 	return &c, nil
 }
 
+// targetLanguages are the struct types of the target config of every supported language
+var targetLanguages = map[string]reflect.Type{
+	"go":         reflect.TypeOf(GoTarget{}),
+	"python":     reflect.TypeOf(PythonTarget{}),
+	"typescript": reflect.TypeOf(TypeScriptTarget{}),
+	"java":       reflect.TypeOf(JavaTarget{}),
+	"csharp":     reflect.TypeOf(CSharpTarget{}),
+}
+
+// setDefaults fills in the optional fields of the target derived from the config name
+func (t *Target) setDefaults(name string) {
+	switch {
+	case t.Go != nil:
+		if t.Go.PackageName == "" {
+			t.Go.PackageName = strings.ReplaceAll(name, "-", "")
+		}
+	case t.Python != nil:
+		if t.Python.DistName == "" && name != "" {
+			t.Python.DistName = "cdktf-provider-" + name
+		}
+		if t.Python.Module == "" {
+			t.Python.Module = strings.ReplaceAll(t.Python.DistName, "-", "_")
+		}
+	case t.TypeScript != nil:
+		t.TypeScript.Scope = strings.TrimPrefix(t.TypeScript.Scope, "@")
+		if t.TypeScript.PackageName == "" && name != "" {
+			if t.TypeScript.Scope == "" {
+				t.TypeScript.Scope = "cdktf"
+			}
+			t.TypeScript.PackageName = "provider-" + name
+		}
+	case t.Java != nil:
+		if t.Java.ArtifactID == "" && name != "" {
+			t.Java.ArtifactID = "cdktf-provider-" + name
+		}
+		if t.Java.Package == "" && t.Java.GroupID != "" && name != "" {
+			t.Java.Package = strings.ReplaceAll(t.Java.GroupID+"."+name, "-", "_")
+		}
+	case t.CSharp != nil:
+		if t.CSharp.PackageID == "" && name != "" {
+			t.CSharp.PackageID = "Cdktf.Providers." + pascalCase(name)
+		}
+		if t.CSharp.Namespace == "" {
+			t.CSharp.Namespace = t.CSharp.PackageID
+		}
+	}
+}
+
+// pascalCase converts a hyphen or underscore separated name to pascal case, e.g., google-beta to GoogleBeta
+func pascalCase(name string) string {
+	var sb strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' }) {
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return sb.String()
+}
+
+// Language returns the language of the target
+func (t *Target) Language() string {
+	switch {
+	case t.Go != nil:
+		return "go"
+	case t.Python != nil:
+		return "python"
+	case t.TypeScript != nil:
+		return "typescript"
+	case t.Java != nil:
+		return "java"
+	case t.CSharp != nil:
+		return "csharp"
+	}
+	return ""
+}
+
+// TypeScript returns the typescript target, nil if there is none
+func (t Targets) TypeScript() *TypeScriptTarget {
+	for _, target := range t {
+		if target.TypeScript != nil {
+			return target.TypeScript
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON accepts either a single target or a list of targets
+func (t *Targets) UnmarshalJSON(b []byte) error {
+	if b = bytes.TrimSpace(b); len(b) > 0 && b[0] == '[' {
+		return json.Unmarshal(b, (*[]*Target)(t))
+	}
+	var target *Target
+	if err := json.Unmarshal(b, &target); err != nil {
+		return err
+	}
+	*t = nil
+	if target != nil {
+		*t = Targets{target}
+	}
+	return nil
+}
+
+// MarshalJSON marshals a single target as an object and multiple targets as a list
+func (t Targets) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]*Target(t))
+}
+
+// Modules are the modules of a config
+type Modules []*cdktf.Source
+
+// setNames sets the name of every module, which is the name of its generated source file.
+// A single module is named after the config, and modules of a list after their source,
+// see cdktf.ModuleSourceName.
+func (m Modules) setNames(name string) {
+	for _, module := range m {
+		if module == nil {
+			continue
+		}
+		module.Name = name
+		if len(m) > 1 {
+			module.Name = cdktf.ModuleSourceName(module.Source)
+		}
+	}
+}
+
+// UnmarshalJSON accepts either a single module or a list of modules
+func (m *Modules) UnmarshalJSON(b []byte) error {
+	if b = bytes.TrimSpace(b); len(b) > 0 && b[0] == '[' {
+		return json.Unmarshal(b, (*[]*cdktf.Source)(m))
+	}
+	var module *cdktf.Source
+	if err := json.Unmarshal(b, &module); err != nil {
+		return err
+	}
+	*m = nil
+	if module != nil {
+		*m = Modules{module}
+	}
+	return nil
+}
+
+// MarshalJSON marshals a single module as an object and multiple modules as a list
+func (m Modules) MarshalJSON() ([]byte, error) {
+	if len(m) == 1 {
+		return json.Marshal(m[0])
+	}
+	return json.Marshal([]*cdktf.Source(m))
+}
+
 func (t *Target) UnmarshalJSON(b []byte) error {
 	var d struct {
 		Language string `json:"language"`
@@ -70,6 +494,14 @@ func (t *Target) UnmarshalJSON(b []byte) error {
 	switch d.Language {
 	case "go":
 		return json.Unmarshal(b, &t.Go)
+	case "python":
+		return json.Unmarshal(b, &t.Python)
+	case "typescript":
+		return json.Unmarshal(b, &t.TypeScript)
+	case "java":
+		return json.Unmarshal(b, &t.Java)
+	case "csharp":
+		return json.Unmarshal(b, &t.CSharp)
 	}
 	return errors.Newf("unknown target language %q", d.Language)
 }
@@ -78,5 +510,17 @@ func (t Target) MarshalJSON() ([]byte, error) {
 	if t.Go != nil {
 		return json.Marshal(t.Go)
 	}
+	if t.Python != nil {
+		return json.Marshal(t.Python)
+	}
+	if t.TypeScript != nil {
+		return json.Marshal(t.TypeScript)
+	}
+	if t.Java != nil {
+		return json.Marshal(t.Java)
+	}
+	if t.CSharp != nil {
+		return json.Marshal(t.CSharp)
+	}
 	return nil, errors.New("target must have exactly 1 non-nil config")
 }
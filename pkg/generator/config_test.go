@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/hexops/autogold/v2"
@@ -33,11 +34,36 @@ output: gen
 					Source:  "registry.terraform.io/hashicorp/google",
 					Version: "4.69.1",
 				},
-				Target: &Target{Go: &GoTarget{
+				Target: Targets{{Go: &GoTarget{
 					Language:    "go",
 					ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
 					PackageName: "google",
-				}},
+				}}},
+				Output: "gen",
+			}),
+		},
+		{
+			name: "valid go with default package name",
+			b: []byte(`
+name: google-beta
+provider:
+  source: registry.terraform.io/hashicorp/google-beta
+  version: 4.69.1
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`),
+			want: autogold.Expect(&Config{
+				Name: "google-beta", Provider: &cdktf.Source{
+					Source:  "registry.terraform.io/hashicorp/google-beta",
+					Version: "4.69.1",
+				},
+				Target: Targets{{Go: &GoTarget{
+					Language:    "go",
+					ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
+					PackageName: "googlebeta",
+				}}},
 				Output: "gen",
 			}),
 		},
@@ -54,18 +80,229 @@ target:
 output: gen           
 `),
 			want: autogold.Expect(&Config{
-				Name: "gkeprivate", Module: &cdktf.Source{
+				Name: "gkeprivate", Module: Modules{{
+					Name:    "gkeprivate",
 					Source:  "terraform-google-modules/kubernetes-engine/google//modules/beta-private-cluster",
 					Version: "24.0.0",
-				},
-				Target: &Target{Go: &GoTarget{
+				}},
+				Target: Targets{{Go: &GoTarget{
 					Language:    "go",
 					ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
 					PackageName: "gkeprivate",
-				}},
+				}}},
+				Output: "gen",
+			}),
+		},
+		{
+			name: "valid python",
+			b: []byte(`
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+  version: 4.69.1
+target:
+  language: python
+output: gen
+`),
+			want: autogold.Expect(&Config{
+				Name: "google", Provider: &cdktf.Source{
+					Source:  "registry.terraform.io/hashicorp/google",
+					Version: "4.69.1",
+				},
+				Target: Targets{{Python: &PythonTarget{
+					Language: "python",
+					DistName: "cdktf-provider-google",
+					Module:   "cdktf_provider_google",
+				}}},
+				Output: "gen",
+			}),
+		},
+		{
+			name: "invalid: python names",
+			b: []byte(`
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+target:
+  language: python
+  distName: cdktf provider google
+  module: cdktf-provider-google
+output: gen
+`),
+			wantErr: autogold.Expect(`line 7: target.distName: "cdktf provider google" is not a valid python distribution name
+line 8: target.module: "cdktf-provider-google" is not a valid python module name`),
+		},
+		{
+			name: "valid typescript",
+			b: []byte(`
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+  version: 4.69.1
+target:
+  language: typescript
+  scope: "@your-org"
+output: gen
+`),
+			want: autogold.Expect(&Config{
+				Name: "google", Provider: &cdktf.Source{
+					Source:  "registry.terraform.io/hashicorp/google",
+					Version: "4.69.1",
+				},
+				Target: Targets{{TypeScript: &TypeScriptTarget{
+					Language:    "typescript",
+					PackageName: "provider-google",
+					Scope:       "your-org",
+				}}},
+				Output: "gen",
+			}),
+		},
+		{
+			name: "invalid: npm names",
+			b: []byte(`
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+target:
+  language: typescript
+  scope: YourOrg
+  packageName: provider google
+output: gen
+`),
+			wantErr: autogold.Expect(`line 7: target.scope: "YourOrg" is not a valid npm scope
+line 8: target.packageName: "provider google" is not a valid npm package name`),
+		},
+		{
+			name: "valid java",
+			b: []byte(`
+name: google-beta
+provider:
+  source: registry.terraform.io/hashicorp/google-beta
+  version: 4.69.1
+target:
+  language: java
+  groupId: com.sourcegraph
+output: gen
+`),
+			want: autogold.Expect(&Config{
+				Name: "google-beta", Provider: &cdktf.Source{
+					Source:  "registry.terraform.io/hashicorp/google-beta",
+					Version: "4.69.1",
+				},
+				Target: Targets{{Java: &JavaTarget{
+					Language:   "java",
+					GroupID:    "com.sourcegraph",
+					ArtifactID: "cdktf-provider-google-beta",
+					Package:    "com.sourcegraph.google_beta",
+				}}},
+				Output: "gen",
+			}),
+		},
+		{
+			name: "invalid: java without groupId",
+			b: []byte(`
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+target:
+  language: java
+  package: com.sourcegraph.google-provider
+output: gen
+`),
+			wantErr: autogold.Expect(`line 5: target.groupId: maven groupId is required
+line 7: target.package: "com.sourcegraph.google-provider" is not a valid java package name`),
+		},
+		{
+			name: "valid csharp",
+			b: []byte(`
+name: google-beta
+provider:
+  source: registry.terraform.io/hashicorp/google-beta
+  version: 4.69.1
+target:
+  language: csharp
+output: gen
+`),
+			want: autogold.Expect(&Config{
+				Name: "google-beta", Provider: &cdktf.Source{
+					Source:  "registry.terraform.io/hashicorp/google-beta",
+					Version: "4.69.1",
+				},
+				Target: Targets{{CSharp: &CSharpTarget{
+					Language:  "csharp",
+					Namespace: "Cdktf.Providers.GoogleBeta",
+					PackageID: "Cdktf.Providers.GoogleBeta",
+				}}},
 				Output: "gen",
 			}),
 		},
+		{
+			name: "valid multiple targets",
+			b: []byte(`
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+  version: 4.69.1
+target:
+  - language: go
+    moduleName: github.com/sourcegraph/controller-cdktf/gen
+  - language: python
+output: gen
+`),
+			want: autogold.Expect(&Config{
+				Name: "google", Provider: &cdktf.Source{
+					Source:  "registry.terraform.io/hashicorp/google",
+					Version: "4.69.1",
+				},
+				Target: Targets{
+					{Go: &GoTarget{
+						Language:    "go",
+						ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
+						PackageName: "google",
+					}},
+					{Python: &PythonTarget{
+						Language: "python",
+						DistName: "cdktf-provider-google",
+						Module:   "cdktf_provider_google",
+					}},
+				},
+				Output: "gen",
+			}),
+		},
+		{
+			name: "invalid: multiple targets",
+			b: []byte(`
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+target:
+  - language: go
+    moduleName: github.com/sourcegraph/controller-cdktf/gen
+    package: google
+  - language: java
+  - language: go
+    moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`),
+			wantErr: autogold.Expect(`line 8: target[0].package: unknown field`),
+		},
+		{
+			name: "invalid: duplicate target language",
+			b: []byte(`
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+target:
+  - language: go
+    moduleName: github.com/sourcegraph/controller-cdktf/gen
+  - language: java
+  - language: go
+    moduleName: github.com/sourcegraph/controller-cdktf/other
+output: gen
+`),
+			wantErr: autogold.Expect(`line 8: target[1].groupId: maven groupId is required
+line 9: target[2].language: duplicate target language "go"`),
+		},
 		{
 			name: "invalid: both provider and module",
 			b: []byte(`
@@ -81,7 +318,7 @@ target:
   moduleName: github.com/sourcegraph/controller-cdktf/gen
 output: gen           
 `),
-			wantErr: autogold.Expect("provider and module can't be set at the same time"),
+			wantErr: autogold.Expect("line 6: module: provider and module can't be set at the same time"),
 		},
 		{
 			name: "invalid - unsupported target",
@@ -97,6 +334,600 @@ output: gen
             `),
 			wantErr: autogold.Expect(`unmarshal config file: error unmarshaling JSON: while decoding JSON: unknown target language "javascript"`),
 		},
+		{
+			name: "invalid: unknown fields",
+			b: []byte(`
+name: google
+language: go
+provider:
+  source: registry.terraform.io/hashicorp/google
+  versions: 4.69.1
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+  package: google
+output: gen
+`),
+			wantErr: autogold.Expect(`line 3: language: unknown field
+line 6: provider.versions: unknown field
+line 10: target.package: unknown field`),
+		},
+		{
+			name: "invalid: neither provider nor module",
+			b: []byte(`
+name: google
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`),
+			wantErr: autogold.Expect("line 2: one of provider or module is required"),
+		},
+		{
+			name: "invalid: fields",
+			b: []byte(`
+provider:
+  source: registry.terraform.io/hashicorp/google/beta
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen/
+output: gen
+`),
+			wantErr: autogold.Expect(`line 2: name: name is required
+line 3: provider.source: invalid provider source "registry.terraform.io/hashicorp/google/beta": must be in the form of [<hostname>/]<namespace>/<type>
+line 6: target.moduleName: malformed module path "github.com/sourcegraph/controller-cdktf/gen/": trailing slash`),
+		},
+		{
+			name: "invalid: package name",
+			b: []byte(`
+name: google-beta
+provider:
+  source: registry.terraform.io/hashicorp/google-beta
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+  packageName: google-beta
+output: gen
+`),
+			wantErr: autogold.Expect(`line 8: target.packageName: "google-beta" is not a valid Go package name`),
+		},
+		{
+			name: "valid toolchain versions",
+			b: []byte(`
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+  version: 5.40.0
+cdktfVersion: 0.20.8
+terraformVersion: 1.9.3
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`),
+			want: autogold.Expect(&Config{
+				Name: "google", Provider: &cdktf.Source{
+					Source:  "registry.terraform.io/hashicorp/google",
+					Version: "5.40.0",
+				},
+				Target: Targets{{Go: &GoTarget{
+					Language:    "go",
+					ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
+					PackageName: "google",
+				}}},
+				CdktfVersion:     "0.20.8",
+				TerraformVersion: "1.9.3",
+				Output:           "gen",
+			}),
+		},
+		{
+			name: "valid opentofu toolchain",
+			b: []byte(`
+name: google
+provider:
+  source: hashicorp/google
+  version: 6.0.0
+toolchain: opentofu
+terraformVersion: 1.8.3
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`),
+			want: autogold.Expect(&Config{
+				Name: "google", Provider: &cdktf.Source{
+					Source:  "hashicorp/google",
+					Version: "6.0.0",
+				},
+				Target: Targets{{Go: &GoTarget{
+					Language:    "go",
+					ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
+					PackageName: "google",
+				}}},
+				Toolchain:        "opentofu",
+				TerraformVersion: "1.8.3",
+				Output:           "gen",
+			}),
+		},
+		{
+			name: "valid credentials",
+			b: []byte(`
+name: vpc
+module:
+  source: app.terraform.io/example-corp/vpc/aws
+  version: 1.0.0
+credentials:
+  app.terraform.io:
+    token: secret-token
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`),
+			want: autogold.Expect(&Config{
+				Name: "vpc", Module: Modules{{
+					Name:    "vpc",
+					Source:  "app.terraform.io/example-corp/vpc/aws",
+					Version: "1.0.0",
+				}},
+				Target: Targets{{Go: &GoTarget{
+					Language:    "go",
+					ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
+					PackageName: "vpc",
+				}}},
+				Credentials: map[string]HostCredentials{"app.terraform.io": {Token: Secret("secret-token")}},
+				Output:      "gen",
+			}),
+		},
+		{
+			name: "invalid: provider binary without exact version",
+			b: []byte(`
+name: internal
+provider:
+  source: example.com/sourcegraph/internal
+  version: "~> 0.1"
+  binary: bin/terraform-provider-internal
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`),
+			wantErr: autogold.Expect(`line 5: provider.version: "~> 0.1" is not an exact version, it is required with binary and schemaFile`),
+		},
+		{
+			name: "invalid: provider binary and mirror",
+			b: []byte(`
+name: internal
+provider:
+  source: example.com/sourcegraph/internal
+  version: 0.1.0
+  binary: bin/terraform-provider-internal
+  mirror: mirror
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`),
+			wantErr: autogold.Expect("line 7: provider.mirror: binary and mirror can't be set at the same time"),
+		},
+		{
+			name: "invalid: provider schema file and binary",
+			b: []byte(`
+name: internal
+provider:
+  source: example.com/sourcegraph/internal
+  version: 0.1.0
+  binary: bin/terraform-provider-internal
+  schemaFile: schema.json
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`),
+			wantErr: autogold.Expect("line 7: provider.schemaFile: schemaFile can't be set with binary or mirror"),
+		},
+		{
+			name: "valid git module",
+			b: []byte(`
+name: vpc
+module:
+  source: git::https://example.com/vpc.git?ref=v1.2.0
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`),
+			want: autogold.Expect(&Config{
+				Name:   "vpc",
+				Module: Modules{{Name: "vpc", Source: "git::https://example.com/vpc.git?ref=v1.2.0"}},
+				Target: Targets{{Go: &GoTarget{
+					Language:    "go",
+					ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
+					PackageName: "vpc",
+				}}},
+				Output: "gen",
+			}),
+		},
+		{
+			name: "valid list of modules",
+			b: []byte(`
+name: network
+module:
+  - source: terraform-aws-modules/vpc/aws
+    version: 5.0.0
+  - source: git::https://example.com/security-group.git?ref=v1.2.0
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`),
+			want: autogold.Expect(&Config{
+				Name: "network",
+				Module: Modules{
+					{
+						Name:    "vpc",
+						Source:  "terraform-aws-modules/vpc/aws",
+						Version: "5.0.0",
+					},
+					{
+						Name:   "security-group",
+						Source: "git::https://example.com/security-group.git?ref=v1.2.0",
+					},
+				},
+				Target: Targets{{Go: &GoTarget{
+					Language:    "go",
+					ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
+					PackageName: "network",
+				}}},
+				Output: "gen",
+			}),
+		},
+		{
+			name: "invalid: duplicate module names",
+			b: []byte(`
+name: network
+module:
+  - source: terraform-aws-modules/vpc/aws
+  - source: git::https://example.com/vpc.git
+    version: 1.0.0
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`),
+			wantErr: autogold.Expect(`line 5: module[1].source: duplicate module name "vpc", modules are named after the last element of their source
+line 6: module[1].version: git module sources have no versions, pin git sources with ?ref= instead`),
+		},
+		{
+			name: "invalid: version of http module source",
+			b: []byte(`
+name: vpc
+module:
+  source: https://example.com/vpc-module.zip
+  version: 1.0.0
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`),
+			wantErr: autogold.Expect("line 5: module.version: http module sources have no versions, pin git sources with ?ref= instead"),
+		},
+		{
+			name: "invalid: unsupported module source",
+			b: []byte(`
+name: vpc
+module:
+  source: s3::https://s3-eu-west-1.amazonaws.com/modules/vpc.zip
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`),
+			wantErr: autogold.Expect(`line 4: module.source: unsupported module source "s3::https://s3-eu-west-1.amazonaws.com/modules/vpc.zip": must be a registry, git, http or local source`),
+		},
+		{
+			name: "invalid: module mirror",
+			b: []byte(`
+name: vpc
+module:
+  source: terraform-aws-modules/vpc/aws
+  mirror: mirror
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`),
+			wantErr: autogold.Expect("line 5: module.mirror: mirror is only supported by providers"),
+		},
+		{
+			name: "invalid: resource filters",
+			b: []byte(`
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+  include:
+    - google_compute_*
+    - google_[storage
+    - "!"
+  exclude:
+    - "!google_*_iam_*"
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`),
+			wantErr: autogold.Expect(`line 7: provider.include[1]: "google_[storage" is not a valid pattern
+line 8: provider.include[2]: pattern is empty
+line 10: provider.exclude[0]: "!google_*_iam_*" is negated, negated patterns are only supported by include`),
+		},
+		{
+			name: "invalid: module include",
+			b: []byte(`
+name: vpc
+module:
+  source: terraform-aws-modules/vpc/aws
+  include: [aws_vpc]
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`),
+			wantErr: autogold.Expect("line 5: module.include: include is only supported by providers"),
+		},
+		{
+			name: "valid go split",
+			b: []byte(`
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+  split:
+    groups:
+      - name: compute
+        packages: [compute*]
+output: gen
+`),
+			want: autogold.Expect(&Config{
+				Name:     "google",
+				Provider: &cdktf.Source{Source: "registry.terraform.io/hashicorp/google"},
+				Target: Targets{{Go: &GoTarget{
+					Language:    "go",
+					ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
+					PackageName: "google",
+					Split: &GoSplit{
+						Groups: []GoModuleGroup{{
+							Name:     "compute",
+							Packages: []string{"compute*"},
+						}},
+					},
+				}}},
+				Output: "gen",
+			}),
+		},
+		{
+			name: "valid go major version templates",
+			b: []byte(`
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+  version: ~> 5.0
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+  packageName: google{{ .Major }}
+  versionSuffix: -devpreview
+output: gen/v{{ .Major }}
+`),
+			want: autogold.Expect(&Config{
+				Name: "google",
+				Provider: &cdktf.Source{
+					Source:  "registry.terraform.io/hashicorp/google",
+					Version: "~> 5.0",
+				},
+				Target: Targets{{Go: &GoTarget{
+					Language:      "go",
+					ModuleName:    "github.com/sourcegraph/controller-cdktf/gen",
+					PackageName:   "google{{ .Major }}",
+					VersionSuffix: "-devpreview",
+				}}},
+				Output: "gen/v{{ .Major }}",
+			}),
+		},
+		{
+			name: "invalid: go major version templates",
+			b: []byte(`
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+  packageName: google-{{ .Major }}
+  majorVersion: -1
+  versionSuffix: devpreview
+output: gen/{{ .Minor }}
+`),
+			wantErr: autogold.Expect(`line 11: output: render template: template: :1:7: executing "" at <.Minor>: can't evaluate field Minor in type generator.VersionData
+line 8: target.packageName: "google-{{ .Major }}" is not a valid Go package name
+line 9: target.majorVersion: -1 is not a valid major version
+line 10: target.versionSuffix: "devpreview" is not a valid version suffix, e.g., -devpreview`),
+		},
+		{
+			name: "valid go.mod directives",
+			b: []byte(`
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+  goMod:
+    go: "1.22"
+    toolchain: go1.22.5
+    replace:
+      - path: github.com/aws/jsii-runtime-go
+        newPath: github.com/sourcegraph/jsii-runtime-go
+        newVersion: v1.98.1
+    retract:
+      - low: v4.69.0
+        rationale: broken provider construct
+    exclude:
+      - path: github.com/aws/jsii-runtime-go
+        version: v1.97.0
+output: gen
+`),
+			want: autogold.Expect(&Config{
+				Name:     "google",
+				Provider: &cdktf.Source{Source: "registry.terraform.io/hashicorp/google"},
+				Target: Targets{{Go: &GoTarget{
+					Language:    "go",
+					ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
+					PackageName: "google",
+					GoMod: &GoMod{
+						Go:        "1.22",
+						Toolchain: "go1.22.5",
+						Replace: []GoModReplace{{
+							Path:       "github.com/aws/jsii-runtime-go",
+							NewPath:    "github.com/sourcegraph/jsii-runtime-go",
+							NewVersion: "v1.98.1",
+						}},
+						Retract: []GoModRetract{{
+							Low:       "v4.69.0",
+							Rationale: "broken provider construct",
+						}},
+						Exclude: []GoModExclude{{
+							Path:    "github.com/aws/jsii-runtime-go",
+							Version: "v1.97.0",
+						}},
+					},
+				}}},
+				Output: "gen",
+			}),
+		},
+		{
+			name: "invalid: go.mod directives",
+			b: []byte(`
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+  goMod:
+    go: go1.22
+    toolchain: "1.22"
+    replace:
+      - path: github.com/aws/jsii-runtime-go
+        newPath: github.com/sourcegraph/jsii-runtime-go
+      - path: github.com/aws/jsii-runtime-go
+        newPath: ../jsii-runtime-go
+        newVersion: v1.98.1
+    retract:
+      - low: v4.69.1
+        high: v4.69.0
+    exclude:
+      - path: github.com/aws/jsii-runtime-go
+        version: 1.97.0
+output: gen
+`),
+			wantErr: autogold.Expect(`line 9: target.goMod.go: "go1.22" is not a valid go version, e.g., 1.22
+line 10: target.goMod.toolchain: "1.22" is not a valid toolchain, e.g., go1.22.5
+line 12: target.goMod.replace[0].newVersion: "" is not a valid module version, it is required unless newPath is a local directory
+line 16: target.goMod.replace[1].newVersion: local directories have no versions
+line 19: target.goMod.retract[0].high: "v4.69.0" is lower than "v4.69.1"
+line 22: target.goMod.exclude[0].version: "1.97.0" is not a valid module version`),
+		},
+		{
+			name: "invalid: go split",
+			b: []byte(`
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+  split:
+    version: 1.0.0
+    groups:
+      - name: provider
+        packages: [compute*]
+      - name: storage
+        packages: ["storage[bucket"]
+      - name: storage
+output: gen
+`),
+			wantErr: autogold.Expect(`line 9: target.split.version: "1.0.0" is not a valid module version, e.g., v0.1.0
+line 11: target.split.groups[0].name: "provider" is reserved for the core module
+line 14: target.split.groups[1].packages[0]: "storage[bucket" is not a valid pattern
+line 15: target.split.groups[2].name: duplicate group name "storage"
+line 15: target.split.groups[2].packages: at least one package pattern is required`),
+		},
+		{
+			name: "invalid: go split version without major version suffix",
+			b: []byte(`
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+  split:
+    version: v4.69.1
+output: gen
+`),
+			wantErr: autogold.Expect(`line 9: target.split.version: "v4.69.1" must be a v0 or v1 version, the module has no major version suffix`),
+		},
+		{
+			name: "invalid: go split version of another major version",
+			b: []byte(`
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+  majorVersion: 5
+  split:
+    version: v4.69.1
+output: gen
+`),
+			wantErr: autogold.Expect(`line 10: target.split.version: "v4.69.1" does not match the major version 5 of the module`),
+		},
+		{
+			name: "invalid: credentials without token",
+			b: []byte(`
+name: vpc
+module:
+  source: app.terraform.io/example-corp/vpc/aws
+credentials:
+  app.terraform.io: {}
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`),
+			wantErr: autogold.Expect("line 6: credentials.app.terraform.io.token: token is required"),
+		},
+		{
+			name: "invalid: toolchain versions",
+			b: []byte(`
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+cdktfVersion: ^0.20
+toolchain: tofu
+terraformVersion: "1.9"
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`),
+			wantErr: autogold.Expect(`line 5: cdktfVersion: "^0.20" is not an exact cdktf version
+line 6: toolchain: unknown toolchain "tofu", must be terraform or opentofu
+line 7: terraformVersion: "1.9" is not an exact terraform version`),
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -111,3 +942,12 @@ output: gen
 		})
 	}
 }
+
+func TestSecret(t *testing.T) {
+	b, err := json.Marshal(Config{
+		Name:        "vpc",
+		Credentials: map[string]HostCredentials{"app.terraform.io": {Token: "secret-token"}},
+	})
+	require.NoError(t, err)
+	require.NotContains(t, string(b), "secret-token")
+}
@@ -0,0 +1,96 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// envNamePattern matches a valid environment variable name
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// interpolateNode expands the environment variables in every value of the node, see interpolate.
+// Keys are never expanded. path is the path of the node and is used for errors.
+func interpolateNode(node *yaml.Node, path string, lookupEnv func(string) (string, bool)) FieldErrors {
+	node = resolveNode(node)
+	if node == nil {
+		return nil
+	}
+	var errs FieldErrors
+	switch node.Kind {
+	case yaml.ScalarNode:
+		v, err := interpolate(node.Value, lookupEnv)
+		if err != nil {
+			return FieldErrors{{Path: path, Line: node.Line, Err: err}}
+		}
+		if v != node.Value && node.Style == 0 {
+			// an unquoted value is typed by its interpolated value
+			node.Tag = scalarTag(v)
+		}
+		node.Value = v
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			errs = append(errs, interpolateNode(node.Content[i+1], joinPath(path, node.Content[i].Value), lookupEnv)...)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			errs = append(errs, interpolateNode(item, fmt.Sprintf("%s[%d]", path, i), lookupEnv)...)
+		}
+	}
+	return errs
+}
+
+// interpolate expands the environment variables in s:
+//
+//   - ${VAR} is the value of VAR, which must be set
+//   - ${VAR:-default} is the value of VAR, or default if VAR is unset or empty
+//   - $$ is a literal $
+func interpolate(s string, lookupEnv func(string) (string, bool)) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var sb strings.Builder
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 || i == len(s)-1 {
+			sb.WriteString(s)
+			return sb.String(), nil
+		}
+		sb.WriteString(s[:i])
+		switch s[i+1] {
+		case '$':
+			sb.WriteByte('$')
+			s = s[i+2:]
+			continue
+		case '{':
+		default:
+			sb.WriteByte('$')
+			s = s[i+1:]
+			continue
+		}
+
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", errors.Newf("unterminated variable reference %q", s[i:])
+		}
+		expr := s[i+2 : i+end]
+		s = s[i+end+1:]
+
+		name, fallback, hasFallback := strings.Cut(expr, ":-")
+		if !envNamePattern.MatchString(name) {
+			return "", errors.Newf("invalid variable reference \"${%s}\"", expr)
+		}
+		value, ok := lookupEnv(name)
+		switch {
+		case hasFallback && value == "":
+			value = fallback
+		case !ok:
+			return "", errors.Newf("environment variable %s is not set, use \"${%s:-default}\" to set a default", name, name)
+		}
+		sb.WriteString(value)
+	}
+}
@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestInterpolate(t *testing.T) {
+	env := map[string]string{
+		"GOOGLE_PROVIDER_VERSION": "4.69.1",
+		"MODULE_ROOT":             "github.com/sourcegraph/controller-cdktf",
+		"EMPTY":                   "",
+	}
+	lookupEnv := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	tests := []struct {
+		s       string
+		want    autogold.Value
+		wantErr autogold.Value
+	}{
+		{s: "4.69.1", want: autogold.Expect("4.69.1")},
+		{s: "${GOOGLE_PROVIDER_VERSION}", want: autogold.Expect("4.69.1")},
+		{s: "${MODULE_ROOT}/gen", want: autogold.Expect("github.com/sourcegraph/controller-cdktf/gen")},
+		{s: "${UNSET:-~> 4.69}", want: autogold.Expect("~> 4.69")},
+		{s: "${EMPTY:-gen}", want: autogold.Expect("gen")},
+		{s: "${EMPTY}", want: autogold.Expect("")},
+		{s: "$$HOME and $HOME", want: autogold.Expect("$HOME and $HOME")},
+		{s: "price$", want: autogold.Expect("price$")},
+		{s: "${UNSET}", wantErr: autogold.Expect(`environment variable UNSET is not set, use "${UNSET:-default}" to set a default`)},
+		{s: "${MODULE_ROOT", wantErr: autogold.Expect(`unterminated variable reference "${MODULE_ROOT"`)},
+		{s: "${MODULE-ROOT}", wantErr: autogold.Expect(`invalid variable reference "${MODULE-ROOT}"`)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.s, func(t *testing.T) {
+			got, err := interpolate(tc.s, lookupEnv)
+			if tc.wantErr != nil {
+				require.Error(t, err)
+				tc.wantErr.Equal(t, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			tc.want.Equal(t, got)
+		})
+	}
+}
+
+func TestInterpolateNode(t *testing.T) {
+	env := map[string]string{
+		"COUNT":   "5",
+		"ENABLED": "true",
+		"VERSION": "1.10",
+	}
+	lookupEnv := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+count: ${COUNT}
+quoted: "${COUNT}"
+enabled: ${ENABLED}
+version: ${VERSION}
+`), &node))
+	require.Empty(t, interpolateNode(node.Content[0], "", lookupEnv))
+	got, err := yaml.Marshal(&node)
+	require.NoError(t, err)
+	autogold.Expect(`count: 5
+quoted: "5"
+enabled: true
+version: "1.10"
+`).Equal(t, string(got))
+}
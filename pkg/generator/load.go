@@ -0,0 +1,252 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/cdktf"
+)
+
+// DefaultsFileName is the name of the defaults file merged into every config file
+// in the same directory or any of its sub-directories
+const DefaultsFileName = "cdktf-provider-gen.defaults.yaml"
+
+// LoadOptions are the options of LoadConfigs
+type LoadOptions struct {
+	// DefaultsFile is the path of the defaults file merged into every config.
+	// If empty, the closest DefaultsFileName found by walking up from the
+	// directory of the config file is used, if any.
+	DefaultsFile string
+	// Set are values set on every config by their dot separated path, e.g., provider.version.
+	// They take precedence over every other value and are not interpolated. Integers
+	// and booleans are typed as such, e.g., target.majorVersion=5.
+	Set map[string]string
+}
+
+// LoadConfigs reads the config file at path, see NewConfigs for the supported formats.
+//
+// Before validation, every config is deep merged with, in order of precedence:
+//
+//   - the values of LoadOptions.Set
+//   - the `defaults` of a batch config
+//   - the config file referenced by `extends`, relative to the config file
+//   - the defaults file
+//
+// Relative local paths of a config, e.g., provider.binary or a local module source,
+// are resolved against the directory of the config file.
+func LoadConfigs(path string, opts LoadOptions) ([]*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read config file")
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolve path %q", path)
+	}
+	l := &loader{dir: filepath.Dir(path), file: abs, set: opts.Set}
+	defaultsFile := opts.DefaultsFile
+	if defaultsFile == "" {
+		defaultsFile, err = FindDefaultsFile(l.dir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if defaultsFile != "" {
+		l.defaults, err = l.readBase(defaultsFile, make(map[string]bool))
+		if err != nil {
+			return nil, errors.Wrapf(err, "load defaults file %q", defaultsFile)
+		}
+	}
+	return l.configs(b)
+}
+
+// FindDefaultsFile returns the path of the closest DefaultsFileName in dir or any
+// of its parents, or an empty string if there is none
+func FindDefaultsFile(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", errors.Wrap(err, "resolve config dir")
+	}
+	for {
+		path := filepath.Join(dir, DefaultsFileName)
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() {
+			return path, nil
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return "", errors.Wrap(err, "find defaults file")
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// loader merges the files a config inherits from into the config nodes
+type loader struct {
+	// dir is the directory relative `extends` and local paths of the config file are resolved against
+	dir string
+	// file is the absolute path of the config file, empty if it is not read from a file
+	file string
+	// defaults is the node of the defaults file, nil if there is none
+	defaults *yaml.Node
+	// set are the values set on every config after interpolation by their path
+	set map[string]string
+}
+
+// config merges the inherited files into the config node, expands the environment
+// variables and validates it, path is the path of the config node in the config file.
+func (l *loader) config(node *yaml.Node, path string) (*Config, error) {
+	seen := make(map[string]bool)
+	if l.file != "" {
+		seen[l.file] = true
+	}
+	if err := l.extend(node, l.dir, seen); err != nil {
+		return nil, FieldErrors{err}.withPrefix(path)
+	}
+	mergeNodes(node, l.defaults)
+
+	if errs := interpolateNode(node, "", os.LookupEnv); len(errs) > 0 {
+		lines := make(map[string]int)
+		nodeLines(node, "", lines)
+		return nil, errs.withLines(lines).withPrefix(path)
+	}
+	fields := make([]string, 0, len(l.set))
+	for field := range l.set {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		value := &yaml.Node{Kind: yaml.ScalarNode, Tag: scalarTag(l.set[field]), Value: l.set[field]}
+		if err := setNode(node, field, value); err != nil {
+			return nil, errors.Wrapf(err, "set %q", field)
+		}
+	}
+	config, err := newConfig(node, path)
+	if err != nil {
+		return nil, err
+	}
+	var errs FieldErrors
+	for i, module := range config.Module {
+		modulePath := "module"
+		if len(config.Module) > 1 {
+			modulePath = fmt.Sprintf("module[%d]", i)
+		}
+		if err := l.resolveModule(node, modulePath, module); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs.withPrefix(path)
+	}
+	if config.Provider != nil {
+		config.Provider.Binary = l.resolvePath(config.Provider.Binary)
+		config.Provider.Mirror = l.resolvePath(config.Provider.Mirror)
+		config.Provider.SchemaFile = l.resolvePath(config.Provider.SchemaFile)
+	}
+	return config, nil
+}
+
+// resolveModule resolves the path of a local module source against the directory of
+// the config file, the directory must exist. node is the node of the config and path
+// is the path of the module in the config.
+func (l *loader) resolveModule(node *yaml.Node, path string, module *cdktf.Source) *FieldError {
+	if typ, _ := cdktf.ParseModuleSourceType(module.Source); typ != cdktf.ModuleSourceLocal {
+		return nil
+	}
+	module.LocalPath = l.resolvePath(module.Source)
+	if info, err := os.Stat(module.LocalPath); err != nil || !info.IsDir() {
+		lines := make(map[string]int)
+		nodeLines(node, "", lines)
+		return &FieldError{
+			Path: path + ".source",
+			Line: lineOf(lines, path+".source"),
+			Err:  errors.Newf("local module directory %q does not exist", module.LocalPath),
+		}
+	}
+	return nil
+}
+
+// resolvePath resolves a relative path of a config against the directory of the config file
+func (l *loader) resolvePath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(l.dir, path)
+}
+
+// extend merges the config file referenced by the `extends` field of the node into the node,
+// relative paths are resolved against dir. seen are the files already extended.
+func (l *loader) extend(node *yaml.Node, dir string, seen map[string]bool) *FieldError {
+	extends := mappingValue(node, "extends")
+	if extends == nil {
+		return nil
+	}
+	if extends.Kind != yaml.ScalarNode || extends.Value == "" {
+		return &FieldError{Path: "extends", Line: extends.Line, Err: errors.New("must be the path of a config file")}
+	}
+	path := extends.Value
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	base, err := l.readBase(path, seen)
+	if err != nil {
+		return &FieldError{Path: "extends", Line: extends.Line, Err: err}
+	}
+	mergeNodes(node, base)
+	return nil
+}
+
+// readBase reads a partial config file other configs inherit from, i.e., an extended
+// config or the defaults file, and merges the files it extends itself.
+// The lines of the returned node are cleared, they don't belong to the file of the config.
+func (l *loader) readBase(path string, seen map[string]bool) (*yaml.Node, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolve path %q", path)
+	}
+	if seen[abs] {
+		return nil, errors.Newf("circular extends of %q", path)
+	}
+	seen[abs] = true
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read config file")
+	}
+	node, err := parseNode(b)
+	if err != nil {
+		return nil, errors.Wrap(err, path)
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, errors.Newf("%s: must be a config", path)
+	}
+	if errs := checkFields(node, reflect.TypeOf(Config{}), ""); len(errs) > 0 {
+		return nil, errors.Wrap(errs, path)
+	}
+	if err := l.extend(node, filepath.Dir(path), seen); err != nil {
+		return nil, errors.Wrap(FieldErrors{err}, path)
+	}
+	clearLines(node)
+	return node, nil
+}
+
+// clearLines clears the line of the node and every node in it
+func clearLines(node *yaml.Node) {
+	if node == nil {
+		return
+	}
+	node.Line = 0
+	for _, n := range node.Content {
+		clearLines(n)
+	}
+}
@@ -0,0 +1,419 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hexops/autogold/v2"
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/cdktf"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles writes the files relative to dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+// trimDir makes the paths resolved against the config file relative to dir,
+// so that the configs don't depend on the temp dir
+func trimDir(t *testing.T, dir string, configs []*Config) {
+	t.Helper()
+	rel := func(path *string) {
+		if *path == "" {
+			return
+		}
+		r, err := filepath.Rel(dir, *path)
+		require.NoError(t, err)
+		*path = r
+	}
+	for _, c := range configs {
+		if c.Provider != nil {
+			rel(&c.Provider.Binary)
+			rel(&c.Provider.Mirror)
+			rel(&c.Provider.SchemaFile)
+		}
+		for _, m := range c.Module {
+			rel(&m.LocalPath)
+		}
+	}
+}
+
+func TestLoadConfigs(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		env     map[string]string
+		opts    LoadOptions
+		want    autogold.Value
+		wantErr autogold.Value
+	}{
+		{
+			name: "defaults file and extends",
+			files: map[string]string{
+				DefaultsFileName: `
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`,
+				"providers/google.yaml": `
+extends: ../base.yaml
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+`,
+				"base.yaml": `
+provider:
+  version: 4.69.1
+target:
+  packageName: googleprovider
+output: providers
+`,
+			},
+			want: autogold.Expect([]*Config{{
+				Name: "google", Extends: "../base.yaml", Provider: &cdktf.Source{
+					Source:  "registry.terraform.io/hashicorp/google",
+					Version: "4.69.1",
+				},
+				Target: Targets{{Go: &GoTarget{
+					Language:    "go",
+					ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
+					PackageName: "googleprovider",
+				}}},
+				Output: "providers",
+			}}),
+		},
+		{
+			name: "batch defaults take precedence over the defaults file",
+			files: map[string]string{
+				DefaultsFileName: `
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`,
+				"providers/google.yaml": `
+defaults:
+  output: providers
+configs:
+  - name: google
+    provider:
+      source: registry.terraform.io/hashicorp/google
+`,
+			},
+			want: autogold.Expect([]*Config{{
+				Name: "google", Provider: &cdktf.Source{
+					Source: "registry.terraform.io/hashicorp/google",
+				},
+				Target: Targets{{Go: &GoTarget{
+					Language:    "go",
+					ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
+					PackageName: "google",
+				}}},
+				Output: "providers",
+			}}),
+		},
+		{
+			name: "explicit defaults file",
+			files: map[string]string{
+				DefaultsFileName: `
+target:
+  language: python
+`,
+				"go.defaults.yaml": `
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+`,
+				"providers/google.yaml": `
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+output: gen
+`,
+			},
+			opts: LoadOptions{DefaultsFile: "go.defaults.yaml"},
+			want: autogold.Expect([]*Config{{
+				Name: "google", Provider: &cdktf.Source{
+					Source: "registry.terraform.io/hashicorp/google",
+				},
+				Target: Targets{{Go: &GoTarget{
+					Language:    "go",
+					ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
+					PackageName: "google",
+				}}},
+				Output: "gen",
+			}}),
+		},
+		{
+			name: "environment variables and set values",
+			files: map[string]string{
+				"providers/google.yaml": `
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+  version: ${GOOGLE_PROVIDER_VERSION}
+target:
+  language: go
+  moduleName: ${MODULE_ROOT}/gen
+output: ${OUTPUT:-gen}
+`,
+			},
+			env: map[string]string{
+				"GOOGLE_PROVIDER_VERSION": "4.69.1",
+				"MODULE_ROOT":             "github.com/sourcegraph/controller-cdktf",
+			},
+			opts: LoadOptions{Set: map[string]string{
+				"provider.version":    "4.70.0",
+				"target.packageName":  "googleprovider",
+				"target.majorVersion": "5",
+			}},
+			want: autogold.Expect([]*Config{{
+				Name: "google", Provider: &cdktf.Source{
+					Source:  "registry.terraform.io/hashicorp/google",
+					Version: "4.70.0",
+				},
+				Target: Targets{{Go: &GoTarget{
+					Language:     "go",
+					ModuleName:   "github.com/sourcegraph/controller-cdktf/gen",
+					PackageName:  "googleprovider",
+					MajorVersion: 5,
+				}}},
+				Output: "gen",
+			}}),
+		},
+		{
+			name: "integer environment variable",
+			files: map[string]string{
+				"providers/google.yaml": `
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+  version: ${GOOGLE_PROVIDER_VERSION}
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+  majorVersion: ${GOOGLE_MAJOR_VERSION}
+output: gen
+`,
+			},
+			env: map[string]string{
+				"GOOGLE_PROVIDER_VERSION": "5",
+				"GOOGLE_MAJOR_VERSION":    "5",
+			},
+			want: autogold.Expect([]*Config{{
+				Name: "google", Provider: &cdktf.Source{
+					Source:  "registry.terraform.io/hashicorp/google",
+					Version: "5",
+				},
+				Target: Targets{{Go: &GoTarget{
+					Language:     "go",
+					ModuleName:   "github.com/sourcegraph/controller-cdktf/gen",
+					PackageName:  "google",
+					MajorVersion: 5,
+				}}},
+				Output: "gen",
+			}}),
+		},
+		{
+			name: "local provider binary relative to the config file",
+			files: map[string]string{
+				"providers/google.yaml": `
+name: google
+provider:
+  source: example.com/sourcegraph/google
+  version: 0.1.0
+  binary: ../bin/terraform-provider-google
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`,
+			},
+			want: autogold.Expect([]*Config{{
+				Name: "google", Provider: &cdktf.Source{
+					Source:  "example.com/sourcegraph/google",
+					Version: "0.1.0",
+					Binary:  "bin/terraform-provider-google",
+				},
+				Target: Targets{{Go: &GoTarget{
+					Language:    "go",
+					ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
+					PackageName: "google",
+				}}},
+				Output: "gen",
+			}}),
+		},
+		{
+			name: "local module relative to the config file",
+			files: map[string]string{
+				"modules/vpc/main.tf": "",
+				"providers/google.yaml": `
+name: google
+module:
+  source: ../modules/vpc
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`,
+			},
+			want: autogold.Expect([]*Config{{
+				Name: "google", Module: Modules{{
+					Name:      "google",
+					Source:    "../modules/vpc",
+					LocalPath: "modules/vpc",
+				}},
+				Target: Targets{{Go: &GoTarget{
+					Language:    "go",
+					ModuleName:  "github.com/sourcegraph/controller-cdktf/gen",
+					PackageName: "google",
+				}}},
+				Output: "gen",
+			}}),
+		},
+		{
+			name: "invalid: missing local module",
+			files: map[string]string{
+				"providers/google.yaml": `
+name: google
+module:
+  source: ./modules/vpc
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`,
+			},
+			wantErr: autogold.Expect(`line 4: module.source: local module directory "providers/modules/vpc" does not exist`),
+		},
+		{
+			name: "invalid: unset environment variable",
+			files: map[string]string{
+				DefaultsFileName: `
+target:
+  language: go
+  moduleName: ${MODULE_ROOT}/gen
+`,
+				"providers/google.yaml": `
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+  version: ${GOOGLE_PROVIDER_VERSION}
+output: gen
+`,
+			},
+			wantErr: autogold.Expect(`line 5: provider.version: environment variable GOOGLE_PROVIDER_VERSION is not set, use "${GOOGLE_PROVIDER_VERSION:-default}" to set a default
+line 2: target.moduleName: environment variable MODULE_ROOT is not set, use "${MODULE_ROOT:-default}" to set a default`),
+		},
+		{
+			name: "invalid: set unknown field",
+			files: map[string]string{
+				"providers/google.yaml": `
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+target:
+  language: go
+  moduleName: github.com/sourcegraph/controller-cdktf/gen
+output: gen
+`,
+			},
+			opts:    LoadOptions{Set: map[string]string{"provider.versions": "4.69.1"}},
+			wantErr: autogold.Expect("provider.versions: unknown field"),
+		},
+		{
+			name: "invalid: inherited value",
+			files: map[string]string{
+				DefaultsFileName: `
+target:
+  language: go
+  moduleName: sourcegraph/controller-cdktf/gen
+`,
+				"providers/google.yaml": `
+name: google
+provider:
+  source: registry.terraform.io/hashicorp/google
+output: gen
+`,
+			},
+			wantErr: autogold.Expect(`line 2: target.moduleName: malformed module path "sourcegraph/controller-cdktf/gen": missing dot in first path element`),
+		},
+		{
+			name: "invalid: unknown field in extended file",
+			files: map[string]string{
+				"providers/google.yaml": `
+name: google
+extends: base.yaml
+`,
+				"providers/base.yaml": `
+target:
+  language: go
+  module: github.com/sourcegraph/controller-cdktf/gen
+`,
+			},
+			wantErr: autogold.Expect(`line 3: extends: providers/base.yaml: line 4: target.module: unknown field`),
+		},
+		{
+			name: "invalid: circular extends",
+			files: map[string]string{
+				"providers/google.yaml": `
+name: google
+extends: base.yaml
+`,
+				"providers/base.yaml": `
+extends: ./google.yaml
+`,
+			},
+			wantErr: autogold.Expect(`line 3: extends: providers/base.yaml: line 2: extends: circular extends of "providers/google.yaml"`),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+			dir := t.TempDir()
+			writeFiles(t, dir, tc.files)
+			if tc.opts.DefaultsFile != "" {
+				tc.opts.DefaultsFile = filepath.Join(dir, tc.opts.DefaultsFile)
+			}
+
+			got, err := LoadConfigs(filepath.Join(dir, "providers", "google.yaml"), tc.opts)
+			if tc.wantErr != nil {
+				require.Error(t, err)
+				// errors don't depend on the temp dir
+				tc.wantErr.Equal(t, strings.ReplaceAll(err.Error(), dir+string(filepath.Separator), ""))
+				return
+			}
+			require.NoError(t, err)
+			trimDir(t, dir, got)
+			tc.want.Equal(t, got)
+		})
+	}
+}
+
+func TestFindDefaultsFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		DefaultsFileName:                       "output: gen",
+		filepath.Join("a", "b", "google.yaml"): "name: google",
+		filepath.Join("c", DefaultsFileName):   "output: c",
+		filepath.Join("c", "d", "random.yaml"): "name: random",
+	})
+
+	got, err := FindDefaultsFile(filepath.Join(dir, "a", "b"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, DefaultsFileName), got)
+
+	got, err = FindDefaultsFile(filepath.Join(dir, "c", "d"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "c", DefaultsFileName), got)
+}
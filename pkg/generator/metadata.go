@@ -0,0 +1,62 @@
+package generator
+
+import "encoding/json"
+
+// MetadataFileName is the name of the metadata file written to every output directory
+const MetadataFileName = "cdktf-provider-gen.json"
+
+// Metadata records how the code in an output directory was generated
+type Metadata struct {
+	Name     string          `json:"name"`
+	Provider *SourceMetadata `json:"provider,omitempty"`
+	// Module is a single object for a single module, and a list for multiple modules
+	Module ModulesMetadata `json:"module,omitempty"`
+
+	CdktfVersion     string `json:"cdktfVersion"`
+	Toolchain        string `json:"toolchain"`
+	TerraformVersion string `json:"terraformVersion"`
+}
+
+// SourceMetadata records the provider or module the code was generated from
+type SourceMetadata struct {
+	Source string `json:"source"`
+	// Version is the exact version the code was generated from
+	Version string `json:"version,omitempty"`
+	// Constraint is the version constraint from the config it was resolved from, if any
+	Constraint string `json:"constraint,omitempty"`
+	// Ref is the ref of a git module source the code was generated from, e.g., v1.2.0, if any
+	Ref string `json:"ref,omitempty"`
+	// Include and Exclude are the patterns of the resources and data sources
+	// the code was generated for, if any
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// Version returns the version of the provider, or the version of the modules if all of them
+// have the same version, otherwise an empty string
+func (m *Metadata) Version() string {
+	switch {
+	case m.Provider != nil:
+		return m.Provider.Version
+	case len(m.Module) > 0:
+		v := m.Module[0].Version
+		for _, module := range m.Module[1:] {
+			if module.Version != v {
+				return ""
+			}
+		}
+		return v
+	}
+	return ""
+}
+
+// ModulesMetadata records the modules the code was generated from
+type ModulesMetadata []*SourceMetadata
+
+// MarshalJSON marshals a single module as an object and multiple modules as a list
+func (m ModulesMetadata) MarshalJSON() ([]byte, error) {
+	if len(m) == 1 {
+		return json.Marshal(m[0])
+	}
+	return json.Marshal([]*SourceMetadata(m))
+}
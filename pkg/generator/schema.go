@@ -0,0 +1,168 @@
+package generator
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/cdktf"
+)
+
+// JSONSchemaID is the draft of the JSON Schema returned by JSONSchema,
+// it is the most widely supported draft by editors.
+const JSONSchemaID = "http://json-schema.org/draft-07/schema#"
+
+// JSONSchema returns the JSON Schema of the config file format,
+// it accepts a single config, a list of configs or a batch config.
+// The schema is meant for editor autocompletion, semantic checks such as
+// required fields are left to NewConfig.
+func JSONSchema() map[string]any {
+	r := &schemaReflector{definitions: make(map[string]any)}
+	config := r.reflect(reflect.TypeOf(Config{}))
+	batch := r.reflect(reflect.TypeOf(Batch{}))
+	return map[string]any{
+		"$schema":     JSONSchemaID,
+		"title":       "cdktf-provider-gen config",
+		"definitions": r.definitions,
+		"oneOf": []any{
+			config,
+			map[string]any{"type": "array", "items": config},
+			batch,
+		},
+	}
+}
+
+// jsonSchemaer can be implemented by types that can't be described by their fields
+type jsonSchemaer interface {
+	jsonSchema(r *schemaReflector) map[string]any
+}
+
+var jsonSchemaerType = reflect.TypeOf((*jsonSchemaer)(nil)).Elem()
+
+// schemaReflector builds JSON Schema from Go types using their json tags.
+// Struct types are registered in definitions and referenced by name.
+// Fields tagged with `jsonschema:"-"` are omitted.
+type schemaReflector struct {
+	definitions map[string]any
+}
+
+func (r *schemaReflector) reflect(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Implements(jsonSchemaerType) {
+		return r.define(t, func() map[string]any {
+			return reflect.Zero(t).Interface().(jsonSchemaer).jsonSchema(r)
+		})
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": r.reflect(t.Elem())}
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.Interface {
+			return map[string]any{"type": "object"}
+		}
+		return map[string]any{"type": "object", "additionalProperties": r.reflect(t.Elem())}
+	case reflect.Struct:
+		return r.define(t, func() map[string]any {
+			return r.reflectStruct(t)
+		})
+	}
+	// anything else, e.g., interfaces, accepts any value
+	return map[string]any{}
+}
+
+// define registers the schema built by fn under the type name and returns a reference to it
+func (r *schemaReflector) define(t reflect.Type, fn func() map[string]any) map[string]any {
+	ref := map[string]any{"$ref": "#/definitions/" + t.Name()}
+	if _, ok := r.definitions[t.Name()]; ok {
+		return ref
+	}
+	// register a placeholder first so recursive types terminate
+	r.definitions[t.Name()] = map[string]any{}
+	r.definitions[t.Name()] = fn()
+	return ref
+}
+
+func (r *schemaReflector) reflectStruct(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	for name, f := range configFields(t) {
+		properties[name] = r.reflect(f.Type)
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// targetSchema returns the schema of a target config selected by the given language
+func targetSchema(r *schemaReflector, language string, t reflect.Type) map[string]any {
+	return map[string]any{
+		"allOf": []any{
+			r.reflect(t),
+			map[string]any{
+				"properties": map[string]any{
+					"language": map[string]any{"const": language},
+				},
+				"required": []any{"language"},
+			},
+		},
+	}
+}
+
+func (Target) jsonSchema(r *schemaReflector) map[string]any {
+	languages := make([]string, 0, len(targetLanguages))
+	for language := range targetLanguages {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	oneOf := make([]any, 0, len(languages))
+	for _, language := range languages {
+		oneOf = append(oneOf, targetSchema(r, language, targetLanguages[language]))
+	}
+	return map[string]any{"oneOf": oneOf}
+}
+
+func (Targets) jsonSchema(r *schemaReflector) map[string]any {
+	target := r.reflect(reflect.TypeOf(Target{}))
+	return map[string]any{
+		"oneOf": []any{
+			target,
+			map[string]any{"type": "array", "items": target, "minItems": 1},
+		},
+	}
+}
+
+func (Modules) jsonSchema(r *schemaReflector) map[string]any {
+	module := r.reflect(reflect.TypeOf(cdktf.Source{}))
+	return map[string]any{
+		"oneOf": []any{
+			module,
+			map[string]any{"type": "array", "items": module, "minItems": 1},
+		},
+	}
+}
+
+func (Batch) jsonSchema(r *schemaReflector) map[string]any {
+	config := r.reflect(reflect.TypeOf(Config{}))
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"defaults": config,
+			"configs":  map[string]any{"type": "array", "items": config},
+		},
+		"required":             []any{"configs"},
+		"additionalProperties": false,
+	}
+}
@@ -0,0 +1,77 @@
+package generator
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	schema := JSONSchema()
+	_, err := json.Marshal(schema)
+	require.NoError(t, err)
+
+	definitions := schema["definitions"].(map[string]any)
+	var names []string
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	autogold.Expect([]string{"Batch", "CSharpTarget", "Config", "GoMod", "GoModExclude", "GoModReplace", "GoModRetract", "GoModuleGroup", "GoSplit", "GoTarget", "HostCredentials", "JavaTarget", "Modules", "PythonTarget", "Source", "Target", "Targets", "TypeScriptTarget"}).Equal(t, names)
+
+	t.Run("properties match the config fields", func(t *testing.T) {
+		seen := make(map[reflect.Type]bool)
+		var check func(typ reflect.Type)
+		check = func(typ reflect.Type) {
+			for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map {
+				typ = typ.Elem()
+			}
+			if typ.Kind() != reflect.Struct || seen[typ] {
+				return
+			}
+			seen[typ] = true
+
+			if typ == reflect.TypeOf(Target{}) {
+				// a target is one of the language targets
+				var fields, languages []reflect.Type
+				for i := 0; i < typ.NumField(); i++ {
+					fields = append(fields, typ.Field(i).Type.Elem())
+				}
+				for _, language := range targetLanguages {
+					languages = append(languages, language)
+				}
+				require.ElementsMatch(t, languages, fields)
+				for _, language := range languages {
+					check(language)
+				}
+				return
+			}
+
+			definition, ok := definitions[typ.Name()].(map[string]any)
+			require.True(t, ok, "definition of %s", typ.Name())
+			properties, ok := definition["properties"].(map[string]any)
+			require.True(t, ok, "properties of %s", typ.Name())
+			var fieldNames, propertyNames []string
+			for name, f := range configFields(typ) {
+				fieldNames = append(fieldNames, name)
+				check(f.Type)
+			}
+			for name := range properties {
+				propertyNames = append(propertyNames, name)
+			}
+			require.ElementsMatch(t, fieldNames, propertyNames, "properties of %s", typ.Name())
+		}
+		check(reflect.TypeOf(Batch{}))
+		check(reflect.TypeOf(Config{}))
+	})
+
+	t.Run("internal fields are omitted", func(t *testing.T) {
+		properties := definitions["Source"].(map[string]any)["properties"].(map[string]any)
+		require.NotContains(t, properties, "name")
+		require.Contains(t, properties, "source")
+	})
+}
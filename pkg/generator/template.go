@@ -0,0 +1,91 @@
+package generator
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+	"golang.org/x/mod/semver"
+)
+
+// VersionData is the data of the templates in the output and the Go package name of a config,
+// e.g., google{{ .Major }}. The templates are rendered with the resolved version of the provider
+// or module before generation, so that the outputs of several major versions can coexist.
+type VersionData struct {
+	// Version is the exact version, e.g., 4.69.1
+	Version string
+	// Major is the major version, e.g., 4
+	Major string
+}
+
+// NewVersionData returns the template data of the version, which must be a semver version
+func NewVersionData(version string) (VersionData, error) {
+	if !semver.IsValid("v" + version) {
+		return VersionData{}, errors.Newf("%q is not a semver version", version)
+	}
+	return VersionData{
+		Version: version,
+		Major:   strings.TrimPrefix(semver.Major("v"+version), "v"),
+	}, nil
+}
+
+// sampleVersionData is the data templates are validated with before versions are resolved
+var sampleVersionData = VersionData{Version: "1.0.0", Major: "1"}
+
+// isTemplate returns true if s has any template actions
+func isTemplate(s string) bool {
+	return strings.Contains(s, "{{")
+}
+
+// renderTemplate renders the template s with the data
+func renderTemplate(s string, data VersionData) (string, error) {
+	if !isTemplate(s) {
+		return s, nil
+	}
+	tmpl, err := template.New("").Option("missingkey=error").Parse(s)
+	if err != nil {
+		return "", errors.Wrap(err, "parse template")
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", errors.Wrap(err, "render template")
+	}
+	return b.String(), nil
+}
+
+// HasTemplates returns true if the output or any Go package name of the config is a template
+func (c *Config) HasTemplates() bool {
+	if isTemplate(c.Output) {
+		return true
+	}
+	for _, t := range c.Target {
+		if t.Go != nil && isTemplate(t.Go.PackageName) {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderTemplates renders the templates of the output and the Go package names of the config
+// with the version of the provider or module, see VersionData.
+func (c *Config) RenderTemplates(version string) error {
+	if !c.HasTemplates() {
+		return nil
+	}
+	data, err := NewVersionData(version)
+	if err != nil {
+		return errors.Wrap(err, "templates require the version of the provider or module")
+	}
+	if c.Output, err = renderTemplate(c.Output, data); err != nil {
+		return errors.Wrap(err, "output")
+	}
+	for _, t := range c.Target {
+		if t.Go == nil {
+			continue
+		}
+		if t.Go.PackageName, err = renderTemplate(t.Go.PackageName, data); err != nil {
+			return errors.Wrap(err, "go packageName")
+		}
+	}
+	return nil
+}
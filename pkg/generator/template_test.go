@@ -0,0 +1,49 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderTemplates(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    autogold.Value
+		wantErr autogold.Value
+	}{
+		{
+			name:    "major version",
+			version: "4.69.1",
+			want:    autogold.Expect([]string{"gen/v4", "google4"}),
+		},
+		{
+			name:    "pre-release",
+			version: "5.0.0-beta.1",
+			want:    autogold.Expect([]string{"gen/v5", "google5"}),
+		},
+		{
+			name:    "no version",
+			version: "",
+			wantErr: autogold.Expect(`templates require the version of the provider or module: "" is not a semver version`),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &Config{
+				Output: "gen/v{{ .Major }}",
+				Target: Targets{{Go: &GoTarget{PackageName: "google{{ .Major }}"}}},
+			}
+			err := c.RenderTemplates(tc.version)
+			if tc.wantErr != nil {
+				require.Error(t, err)
+				tc.wantErr.Equal(t, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			tc.want.Equal(t, []string{c.Output, c.Target[0].Go.PackageName})
+		})
+	}
+}
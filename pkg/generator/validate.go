@@ -0,0 +1,473 @@
+package generator
+
+import (
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	hcversion "github.com/hashicorp/go-version"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/cdktf"
+)
+
+// FieldError is a config error of a specific field
+type FieldError struct {
+	// Path is the path of the field, e.g., target.moduleName
+	Path string
+	// Line is the line of the field in the config file, 0 if unknown
+	Line int
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	var sb strings.Builder
+	if e.Line > 0 {
+		fmt.Fprintf(&sb, "line %d: ", e.Line)
+	}
+	if e.Path != "" {
+		fmt.Fprintf(&sb, "%s: ", e.Path)
+	}
+	sb.WriteString(e.Err.Error())
+	return sb.String()
+}
+
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// FieldErrors are all the field errors found in a config file
+type FieldErrors []*FieldError
+
+func (e FieldErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// withLines sets the line of every error from the indexed lines of the config file
+func (e FieldErrors) withLines(lines map[string]int) FieldErrors {
+	for _, err := range e {
+		if err.Line == 0 {
+			err.Line = lineOf(lines, err.Path)
+		}
+	}
+	return e
+}
+
+// withPrefix prefixes the path of every error, e.g., with the path of a batch entry
+func (e FieldErrors) withPrefix(prefix string) FieldErrors {
+	for _, err := range e {
+		if err.Path == "" {
+			err.Path = prefix
+		} else if prefix != "" {
+			err.Path = prefix + "." + err.Path
+		}
+	}
+	return e
+}
+
+// validate reports every semantic error of the decoded config.
+// Paths are relative to the config.
+func (c *Config) validate() FieldErrors {
+	var errs FieldErrors
+	if c.Name == "" {
+		errs = append(errs, &FieldError{Path: "name", Err: errors.New("name is required")})
+	}
+
+	switch {
+	case c.Provider != nil && len(c.Module) > 0:
+		errs = append(errs, &FieldError{Path: "module", Err: errors.New("provider and module can't be set at the same time")})
+	case c.Provider == nil && len(c.Module) == 0:
+		errs = append(errs, &FieldError{Err: errors.New("one of provider or module is required")})
+	}
+	if c.Provider != nil {
+		if _, err := cdktf.ParseProviderAddressWithHost(c.Provider.Source, c.RegistryHost()); err != nil {
+			errs = append(errs, &FieldError{Path: "provider.source", Err: err})
+		}
+		switch {
+		case c.Provider.Binary != "" && c.Provider.Mirror != "":
+			errs = append(errs, &FieldError{Path: "provider.mirror", Err: errors.New("binary and mirror can't be set at the same time")})
+		case c.Provider.SchemaFile != "" && c.Provider.IsLocal():
+			errs = append(errs, &FieldError{Path: "provider.schemaFile", Err: errors.New("schemaFile can't be set with binary or mirror")})
+		}
+		if c.Provider.Binary != "" || c.Provider.SchemaFile != "" {
+			if _, err := hcversion.NewVersion(c.Provider.Version); err != nil {
+				errs = append(errs, &FieldError{Path: "provider.version", Err: errors.Newf("%q is not an exact version, it is required with binary and schemaFile", c.Provider.Version)})
+			}
+		}
+		errs = append(errs, validatePatterns("provider.include", c.Provider.Include, true)...)
+		errs = append(errs, validatePatterns("provider.exclude", c.Provider.Exclude, false)...)
+	}
+	names := make(map[string]bool, len(c.Module))
+	for i, m := range c.Module {
+		path := "module"
+		if len(c.Module) > 1 {
+			path = fmt.Sprintf("module[%d]", i)
+		}
+		if m == nil {
+			errs = append(errs, &FieldError{Path: path, Err: errors.New("module config is required")})
+			continue
+		}
+		typ, err := cdktf.ParseModuleSourceType(m.Source)
+		if err != nil {
+			errs = append(errs, &FieldError{Path: path + ".source", Err: err})
+		}
+		if err == nil && m.Name != "" {
+			if names[m.Name] {
+				errs = append(errs, &FieldError{Path: path + ".source", Err: errors.Newf("duplicate module name %q, modules are named after the last element of their source", m.Name)})
+			}
+			names[m.Name] = true
+		}
+		if err == nil && typ != cdktf.ModuleSourceRegistry && m.Version != "" {
+			errs = append(errs, &FieldError{Path: path + ".version", Err: errors.Newf("%s module sources have no versions, pin git sources with ?ref= instead", typ)})
+		}
+		if m.Binary != "" {
+			errs = append(errs, &FieldError{Path: path + ".binary", Err: errors.New("binary is only supported by providers")})
+		}
+		if m.Mirror != "" {
+			errs = append(errs, &FieldError{Path: path + ".mirror", Err: errors.New("mirror is only supported by providers")})
+		}
+		if m.SchemaFile != "" {
+			errs = append(errs, &FieldError{Path: path + ".schemaFile", Err: errors.New("schemaFile is only supported by providers")})
+		}
+		if len(m.Include) > 0 {
+			errs = append(errs, &FieldError{Path: path + ".include", Err: errors.New("include is only supported by providers")})
+		}
+		if len(m.Exclude) > 0 {
+			errs = append(errs, &FieldError{Path: path + ".exclude", Err: errors.New("exclude is only supported by providers")})
+		}
+	}
+
+	if c.CdktfVersion != "" {
+		if err := ValidateCdktfVersion(c.CdktfVersion); err != nil {
+			errs = append(errs, &FieldError{Path: "cdktfVersion", Err: err})
+		}
+	}
+	hosts := make([]string, 0, len(c.Credentials))
+	for host := range c.Credentials {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		if c.Credentials[host].Token == "" {
+			errs = append(errs, &FieldError{Path: "credentials." + host + ".token", Err: errors.New("token is required")})
+		}
+	}
+
+	switch c.Toolchain {
+	case "", ToolchainTerraform, ToolchainOpenTofu:
+	default:
+		errs = append(errs, &FieldError{Path: "toolchain", Err: errors.Newf("unknown toolchain %q, must be %s or %s", c.Toolchain, ToolchainTerraform, ToolchainOpenTofu)})
+	}
+	if c.TerraformVersion != "" {
+		if err := ValidateTerraformVersion(c.TerraformVersion); err != nil {
+			errs = append(errs, &FieldError{Path: "terraformVersion", Err: err})
+		}
+	}
+
+	if _, err := renderTemplate(c.Output, sampleVersionData); err != nil {
+		errs = append(errs, &FieldError{Path: "output", Err: err})
+	}
+
+	if len(c.Target) == 0 {
+		errs = append(errs, &FieldError{Path: "target", Err: errors.New("language target config is required")})
+	}
+	languages := make(map[string]bool, len(c.Target))
+	for i, t := range c.Target {
+		path := "target"
+		if len(c.Target) > 1 {
+			path = fmt.Sprintf("target[%d]", i)
+		}
+		if t == nil {
+			errs = append(errs, &FieldError{Path: path, Err: errors.New("language target config is required")})
+			continue
+		}
+		if languages[t.Language()] {
+			errs = append(errs, &FieldError{Path: path + ".language", Err: errors.Newf("duplicate target language %q", t.Language())})
+		}
+		languages[t.Language()] = true
+		errs = append(errs, t.validate().withPrefix(path)...)
+	}
+	return errs
+}
+
+// validatePatterns reports every malformed resource filter pattern of the list at path,
+// negated patterns are only allowed if negate is true
+func validatePatterns(path string, patterns []string, negate bool) FieldErrors {
+	var errs FieldErrors
+	for i, pattern := range patterns {
+		path := fmt.Sprintf("%s[%d]", path, i)
+		if !negate && strings.HasPrefix(pattern, "!") {
+			errs = append(errs, &FieldError{Path: path, Err: errors.Newf("%q is negated, negated patterns are only supported by include", pattern)})
+			continue
+		}
+		if err := cdktf.ValidateResourcePattern(pattern); err != nil {
+			errs = append(errs, &FieldError{Path: path, Err: err})
+		}
+	}
+	return errs
+}
+
+// ValidateCdktfVersion returns an error if the version is not an exact cdktf release version, e.g., 0.20.0
+func ValidateCdktfVersion(version string) error {
+	if !semver.IsValid("v"+version) || semver.Canonical("v"+version) != "v"+version {
+		return errors.Newf("%q is not an exact cdktf version", version)
+	}
+	return nil
+}
+
+// ValidateTerraformVersion returns an error if the version is not an exact terraform version, e.g., 1.8.5
+func ValidateTerraformVersion(version string) error {
+	if _, err := hcversion.NewSemver(version); err != nil || strings.HasPrefix(version, "v") || strings.Count(version, ".") != 2 {
+		return errors.Newf("%q is not an exact terraform version", version)
+	}
+	return nil
+}
+
+// validate reports the errors of the target config, paths are relative to the target
+func (t *Target) validate() FieldErrors {
+	switch {
+	case t.Go != nil:
+		return t.Go.validate()
+	case t.Python != nil:
+		return t.Python.validate()
+	case t.TypeScript != nil:
+		return t.TypeScript.validate()
+	case t.Java != nil:
+		return t.Java.validate()
+	case t.CSharp != nil:
+		return t.CSharp.validate()
+	}
+	return nil
+}
+
+func (t *GoTarget) validate() FieldErrors {
+	var errs FieldErrors
+	if t.ModuleName == "" {
+		errs = append(errs, &FieldError{Path: "moduleName", Err: errors.New("module name is required")})
+	} else if err := module.CheckPath(t.ModuleName); err != nil {
+		errs = append(errs, &FieldError{Path: "moduleName", Err: err})
+	}
+	// an empty package name is already reported as an empty name
+	if t.PackageName != "" {
+		// templates are checked with a sample version, they are rendered before generation
+		packageName, err := renderTemplate(t.PackageName, sampleVersionData)
+		switch {
+		case err != nil:
+			errs = append(errs, &FieldError{Path: "packageName", Err: err})
+		case !token.IsIdentifier(packageName):
+			errs = append(errs, &FieldError{Path: "packageName", Err: errors.Newf("%q is not a valid Go package name", t.PackageName)})
+		case t.ModuleName != "":
+			if err := module.CheckImportPath(t.ModuleName + "/" + packageName); err != nil {
+				errs = append(errs, &FieldError{Path: "packageName", Err: err})
+			}
+		}
+	}
+	if t.MajorVersion < 0 {
+		errs = append(errs, &FieldError{Path: "majorVersion", Err: errors.Newf("%d is not a valid major version", t.MajorVersion)})
+	}
+	if t.VersionSuffix != "" && (!strings.HasPrefix(t.VersionSuffix, "-") || !semver.IsValid("v1.0.0"+t.VersionSuffix)) {
+		errs = append(errs, &FieldError{Path: "versionSuffix", Err: errors.Newf("%q is not a valid version suffix, e.g., -devpreview", t.VersionSuffix)})
+	}
+	if t.GoMod != nil {
+		errs = append(errs, t.GoMod.validate().withPrefix("goMod")...)
+	}
+	if t.Split != nil {
+		errs = append(errs, t.Split.validate().withPrefix("split")...)
+		// a derived major version is only known once the provider or module version is resolved
+		if semver.IsValid(t.Split.Version) && (t.MajorVersion > 0 || !t.MajorVersionSuffix) {
+			major := semver.Major(t.Split.Version)
+			switch {
+			case t.MajorVersion >= 2 && major != fmt.Sprintf("v%d", t.MajorVersion):
+				errs = append(errs, &FieldError{Path: "split.version", Err: errors.Newf("%q does not match the major version %d of the module", t.Split.Version, t.MajorVersion)})
+			case t.MajorVersion < 2 && major != "v0" && major != "v1":
+				errs = append(errs, &FieldError{Path: "split.version", Err: errors.Newf("%q must be a v0 or v1 version, the module has no major version suffix", t.Split.Version)})
+			}
+		}
+	}
+	return errs
+}
+
+func (m *GoMod) validate() FieldErrors {
+	var errs FieldErrors
+	if m.Go != "" && !modfile.GoVersionRE.MatchString(m.Go) {
+		errs = append(errs, &FieldError{Path: "go", Err: errors.Newf("%q is not a valid go version, e.g., 1.22", m.Go)})
+	}
+	if m.Toolchain != "" && !modfile.ToolchainRE.MatchString(m.Toolchain) {
+		errs = append(errs, &FieldError{Path: "toolchain", Err: errors.Newf("%q is not a valid toolchain, e.g., go1.22.5", m.Toolchain)})
+	}
+	for i, r := range m.Replace {
+		path := fmt.Sprintf("replace[%d]", i)
+		if err := module.CheckPath(r.Path); err != nil {
+			errs = append(errs, &FieldError{Path: path + ".path", Err: err})
+		}
+		if r.Version != "" && !semver.IsValid(r.Version) {
+			errs = append(errs, &FieldError{Path: path + ".version", Err: errors.Newf("%q is not a valid module version", r.Version)})
+		}
+		switch {
+		case r.NewPath == "":
+			errs = append(errs, &FieldError{Path: path + ".newPath", Err: errors.New("new path is required")})
+		case modfile.IsDirectoryPath(r.NewPath):
+			if r.NewVersion != "" {
+				errs = append(errs, &FieldError{Path: path + ".newVersion", Err: errors.New("local directories have no versions")})
+			}
+		default:
+			if err := module.CheckPath(r.NewPath); err != nil {
+				errs = append(errs, &FieldError{Path: path + ".newPath", Err: err})
+			}
+			if !semver.IsValid(r.NewVersion) {
+				errs = append(errs, &FieldError{Path: path + ".newVersion", Err: errors.Newf("%q is not a valid module version, it is required unless newPath is a local directory", r.NewVersion)})
+			}
+		}
+	}
+	for i, r := range m.Retract {
+		path := fmt.Sprintf("retract[%d]", i)
+		if !semver.IsValid(r.Low) {
+			errs = append(errs, &FieldError{Path: path + ".low", Err: errors.Newf("%q is not a valid module version", r.Low)})
+		}
+		if r.High != "" {
+			if !semver.IsValid(r.High) {
+				errs = append(errs, &FieldError{Path: path + ".high", Err: errors.Newf("%q is not a valid module version", r.High)})
+			} else if semver.IsValid(r.Low) && semver.Compare(r.Low, r.High) > 0 {
+				errs = append(errs, &FieldError{Path: path + ".high", Err: errors.Newf("%q is lower than %q", r.High, r.Low)})
+			}
+		}
+	}
+	for i, e := range m.Exclude {
+		path := fmt.Sprintf("exclude[%d]", i)
+		if err := module.CheckPath(e.Path); err != nil {
+			errs = append(errs, &FieldError{Path: path + ".path", Err: err})
+		}
+		if !semver.IsValid(e.Version) {
+			errs = append(errs, &FieldError{Path: path + ".version", Err: errors.Newf("%q is not a valid module version", e.Version)})
+		}
+	}
+	return errs
+}
+
+func (s *GoSplit) validate() FieldErrors {
+	var errs FieldErrors
+	if s.Version != "" && !semver.IsValid(s.Version) {
+		errs = append(errs, &FieldError{Path: "version", Err: errors.Newf("%q is not a valid module version, e.g., v0.1.0", s.Version)})
+	}
+	names := make(map[string]bool, len(s.Groups))
+	for i, g := range s.Groups {
+		path := fmt.Sprintf("groups[%d]", i)
+		switch {
+		case g.Name == "":
+			errs = append(errs, &FieldError{Path: path + ".name", Err: errors.New("group name is required")})
+		case strings.Contains(g.Name, "/") || module.CheckImportPath(g.Name) != nil:
+			errs = append(errs, &FieldError{Path: path + ".name", Err: errors.Newf("%q is not a valid directory name", g.Name)})
+		case CoreGoPackages[g.Name]:
+			errs = append(errs, &FieldError{Path: path + ".name", Err: errors.Newf("%q is reserved for the core module", g.Name)})
+		case names[g.Name]:
+			errs = append(errs, &FieldError{Path: path + ".name", Err: errors.Newf("duplicate group name %q", g.Name)})
+		}
+		names[g.Name] = true
+		if len(g.Packages) == 0 {
+			errs = append(errs, &FieldError{Path: path + ".packages", Err: errors.New("at least one package pattern is required")})
+		}
+		for j, pattern := range g.Packages {
+			if _, err := filepath.Match(pattern, ""); err != nil || pattern == "" {
+				errs = append(errs, &FieldError{Path: fmt.Sprintf("%s.packages[%d]", path, j), Err: errors.Newf("%q is not a valid pattern", pattern)})
+			}
+		}
+	}
+	return errs
+}
+
+var (
+	// pythonDistNamePattern matches a valid python distribution name
+	// https://packaging.python.org/en/latest/specifications/name-normalization/
+	pythonDistNamePattern = regexp.MustCompile(`^(?i:[a-z0-9]|[a-z0-9][a-z0-9._-]*[a-z0-9])$`)
+	// pythonModulePattern matches a valid, possibly dotted, python module name
+	pythonModulePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+)
+
+func (t *PythonTarget) validate() FieldErrors {
+	var errs FieldErrors
+	// an empty dist name is already reported as an empty name
+	if t.DistName != "" && !pythonDistNamePattern.MatchString(t.DistName) {
+		errs = append(errs, &FieldError{Path: "distName", Err: errors.Newf("%q is not a valid python distribution name", t.DistName)})
+	}
+	if t.Module != "" && !pythonModulePattern.MatchString(t.Module) {
+		errs = append(errs, &FieldError{Path: "module", Err: errors.Newf("%q is not a valid python module name", t.Module)})
+	}
+	return errs
+}
+
+var (
+	// npmNamePartPattern matches a valid npm package name or scope
+	// https://docs.npmjs.com/cli/configuring-npm/package-json#name
+	npmNamePartPattern = regexp.MustCompile(`^[a-z0-9-~][a-z0-9-._~]*$`)
+)
+
+func (t *TypeScriptTarget) validate() FieldErrors {
+	var errs FieldErrors
+	if t.Scope != "" && !npmNamePartPattern.MatchString(t.Scope) {
+		errs = append(errs, &FieldError{Path: "scope", Err: errors.Newf("%q is not a valid npm scope", t.Scope)})
+	}
+	// an empty package name is already reported as an empty name
+	if t.PackageName != "" {
+		if !npmNamePartPattern.MatchString(t.PackageName) {
+			errs = append(errs, &FieldError{Path: "packageName", Err: errors.Newf("%q is not a valid npm package name", t.PackageName)})
+		} else if len(t.NpmName()) > 214 {
+			errs = append(errs, &FieldError{Path: "packageName", Err: errors.Newf("npm package name %q is longer than 214 characters", t.NpmName())})
+		}
+	}
+	return errs
+}
+
+var (
+	// mavenIDPattern matches a valid maven groupId or artifactId
+	mavenIDPattern = regexp.MustCompile(`^[A-Za-z0-9_\-.]+$`)
+	// javaPackagePattern matches a valid java package name
+	javaPackagePattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*(\.[A-Za-z_$][A-Za-z0-9_$]*)*$`)
+)
+
+func (t *JavaTarget) validate() FieldErrors {
+	var errs FieldErrors
+	if t.GroupID == "" {
+		errs = append(errs, &FieldError{Path: "groupId", Err: errors.New("maven groupId is required")})
+	} else if !mavenIDPattern.MatchString(t.GroupID) {
+		errs = append(errs, &FieldError{Path: "groupId", Err: errors.Newf("%q is not a valid maven groupId", t.GroupID)})
+	}
+	// an empty artifactId is already reported as an empty name
+	if t.ArtifactID != "" && !mavenIDPattern.MatchString(t.ArtifactID) {
+		errs = append(errs, &FieldError{Path: "artifactId", Err: errors.Newf("%q is not a valid maven artifactId", t.ArtifactID)})
+	}
+	if t.Package != "" && !javaPackagePattern.MatchString(t.Package) {
+		errs = append(errs, &FieldError{Path: "package", Err: errors.Newf("%q is not a valid java package name", t.Package)})
+	}
+	return errs
+}
+
+var (
+	// nugetIDPattern matches a valid NuGet package id
+	nugetIDPattern = regexp.MustCompile(`^\w+([_.-]\w+)*$`)
+	// dotnetNamespacePattern matches a valid .NET namespace
+	dotnetNamespacePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+)
+
+func (t *CSharpTarget) validate() FieldErrors {
+	var errs FieldErrors
+	// an empty package id is already reported as an empty name
+	if t.PackageID != "" {
+		if !nugetIDPattern.MatchString(t.PackageID) {
+			errs = append(errs, &FieldError{Path: "packageId", Err: errors.Newf("%q is not a valid NuGet package id", t.PackageID)})
+		} else if len(t.PackageID) > 100 {
+			errs = append(errs, &FieldError{Path: "packageId", Err: errors.Newf("NuGet package id %q is longer than 100 characters", t.PackageID)})
+		}
+	}
+	if t.Namespace != "" && !dotnetNamespacePattern.MatchString(t.Namespace) {
+		errs = append(errs, &FieldError{Path: "namespace", Err: errors.Newf("%q is not a valid .NET namespace", t.Namespace)})
+	}
+	return errs
+}
@@ -0,0 +1,280 @@
+package generator
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+	"gopkg.in/yaml.v3"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/cdktf"
+)
+
+// The config file is parsed into a yaml.Node tree first so that every field
+// keeps track of its line for error reporting. The final decoding into Config
+// is still done by sigs.k8s.io/yaml so that the json tags and the custom
+// json.Unmarshaler implementations apply.
+
+// parseNode parses the config file and returns its root node.
+// An empty file is parsed as an empty mapping.
+func parseNode(b []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, errors.Wrap(err, "unmarshal config file")
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: 1, Column: 1}, nil
+	}
+	return resolveNode(doc.Content[0]), nil
+}
+
+// resolveNode follows aliases to the node they point to
+func resolveNode(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// mappingValue returns the value of the key in the mapping node, or nil if not found
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	node = resolveNode(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return resolveNode(node.Content[i+1])
+		}
+	}
+	return nil
+}
+
+// copyNode returns a deep copy of the node
+func copyNode(node *yaml.Node) *yaml.Node {
+	node = resolveNode(node)
+	if node == nil {
+		return nil
+	}
+	c := *node
+	c.Content = make([]*yaml.Node, len(node.Content))
+	for i, n := range node.Content {
+		c.Content[i] = copyNode(n)
+	}
+	return &c
+}
+
+// mergeNodes deep merges the src mapping node into dst.
+// Keys already set in dst take precedence, nested mappings are merged recursively.
+// Values copied from src keep their lines, so errors still point at src.
+func mergeNodes(dst, src *yaml.Node) {
+	dst, src = resolveNode(dst), resolveNode(src)
+	if dst == nil || src == nil || dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		existing := mappingValue(dst, key.Value)
+		if existing == nil {
+			dst.Content = append(dst.Content, copyNode(key), copyNode(value))
+			continue
+		}
+		mergeNodes(existing, value)
+	}
+}
+
+// scalarTag returns the tag of the value as an unquoted YAML scalar, so that integers and
+// booleans can set fields of those types. Everything else is a string, e.g., 1.10 is not
+// a float.
+func scalarTag(value string) string {
+	switch tag := (&yaml.Node{Kind: yaml.ScalarNode, Value: value}).ShortTag(); tag {
+	case "!!int", "!!bool":
+		return tag
+	}
+	return "!!str"
+}
+
+// setNode sets the value at the dot separated path in the mapping node,
+// missing intermediate mappings are created
+func setNode(node *yaml.Node, path string, value *yaml.Node) error {
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		node = resolveNode(node)
+		if key == "" {
+			return errors.Newf("invalid path %q", path)
+		}
+		if node.Kind != yaml.MappingNode {
+			return errors.Newf("%s is not a mapping", strings.Join(keys[:i], "."))
+		}
+
+		last := i == len(keys)-1
+		next := -1
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == key {
+				next = j + 1
+				break
+			}
+		}
+		if next < 0 {
+			child := value
+			if !last {
+				child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+			next = len(node.Content) - 1
+		} else if last {
+			node.Content[next] = value
+		}
+		node = node.Content[next]
+	}
+	return nil
+}
+
+// decodeNode decodes the node into v using the json tags of v
+func decodeNode(node *yaml.Node, v any) error {
+	b, err := yaml.Marshal(node)
+	if err != nil {
+		return errors.Wrap(err, "marshal config")
+	}
+	if err := sigsyaml.Unmarshal(b, v); err != nil {
+		return errors.Wrap(err, "unmarshal config file")
+	}
+	return nil
+}
+
+// nodeLines indexes the line of every field in the node by its field path
+func nodeLines(node *yaml.Node, path string, lines map[string]int) {
+	node = resolveNode(node)
+	if node == nil {
+		return
+	}
+	if _, ok := lines[path]; !ok {
+		lines[path] = node.Line
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			fieldPath := joinPath(path, key.Value)
+			lines[fieldPath] = key.Line
+			nodeLines(node.Content[i+1], fieldPath, lines)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			nodeLines(item, fmt.Sprintf("%s[%d]", path, i), lines)
+		}
+	}
+}
+
+// lineOf returns the line of the field path, or the line of its closest parent
+// if the field is not set or is inherited from another file
+func lineOf(lines map[string]int, path string) int {
+	for {
+		if line := lines[path]; line > 0 {
+			return line
+		}
+		i := strings.LastIndexAny(path, ".[")
+		if i < 0 {
+			return lines[""]
+		}
+		path = path[:i]
+	}
+}
+
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+// checkFields reports every field of the node that is not known by the type t
+func checkFields(node *yaml.Node, t reflect.Type, path string) FieldErrors {
+	node = resolveNode(node)
+	if node == nil {
+		return nil
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if node.Kind != yaml.SequenceNode {
+		switch t {
+		case reflect.TypeOf(Targets{}):
+			// a single target
+			t = reflect.TypeOf(Target{})
+		case reflect.TypeOf(Modules{}):
+			// a single module
+			t = reflect.TypeOf(cdktf.Source{})
+		}
+	}
+	if t == reflect.TypeOf(Target{}) {
+		language := mappingValue(node, "language")
+		if language == nil {
+			// missing or unknown languages are reported by Target.UnmarshalJSON
+			return nil
+		}
+		targetType, ok := targetLanguages[language.Value]
+		if !ok {
+			return nil
+		}
+		t = targetType
+	}
+
+	var errs FieldErrors
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			// type mismatches are reported by the decoder
+			return nil
+		}
+		fields := configFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			fieldPath := joinPath(path, key.Value)
+			f, ok := fields[key.Value]
+			if !ok {
+				errs = append(errs, &FieldError{Path: fieldPath, Line: key.Line, Err: errors.New("unknown field")})
+				continue
+			}
+			errs = append(errs, checkFields(node.Content[i+1], f.Type, fieldPath)...)
+		}
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for i, item := range node.Content {
+			errs = append(errs, checkFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			errs = append(errs, checkFields(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value))...)
+		}
+	}
+	return errs
+}
+
+// configFields returns the fields of the struct type accepted in config files by their json name.
+// Fields tagged with `jsonschema:"-"` are only used internally and not accepted.
+func configFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || f.Tag.Get("jsonschema") == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f
+	}
+	return fields
+}
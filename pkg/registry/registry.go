@@ -0,0 +1,202 @@
+// Package registry implements a minimal client of the Terraform registry protocol,
+// used to resolve version constraints to exact provider and module versions.
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	hcversion "github.com/hashicorp/go-version"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/cdktf"
+)
+
+// Client looks up versions from Terraform registries
+type Client struct {
+	// BaseURL overrides the endpoint of every registry host, e.g., a local stand-in in tests.
+	// If empty, https://<hostname> of the source address is used.
+	BaseURL string
+	// HTTPClient is the client used for every request, defaults to http.DefaultClient
+	HTTPClient *http.Client
+	// Credentials are the tokens by hostname sent as bearer tokens to private registries
+	Credentials map[string]string
+}
+
+// services is the service discovery document of a registry host
+// https://developer.hashicorp.com/terraform/internals/remote-service-discovery
+type services struct {
+	ProvidersV1 string `json:"providers.v1"`
+	ModulesV1   string `json:"modules.v1"`
+}
+
+// ProviderVersions returns every published version of the provider
+func (c *Client) ProviderVersions(ctx context.Context, addr cdktf.ProviderAddress) ([]*hcversion.Version, error) {
+	svc, err := c.discover(ctx, addr.Hostname)
+	if err != nil {
+		return nil, err
+	}
+	if svc.ProvidersV1 == "" {
+		return nil, errors.Newf("registry %q does not support providers", addr.Hostname)
+	}
+
+	var resp struct {
+		Versions []struct {
+			Version string `json:"version"`
+		} `json:"versions"`
+	}
+	if err := c.get(ctx, addr.Hostname, svc.ProvidersV1, fmt.Sprintf("%s/%s/versions", addr.Namespace, addr.Type), &resp); err != nil {
+		return nil, errors.Wrapf(err, "list versions of provider %q", addr)
+	}
+	raw := make([]string, 0, len(resp.Versions))
+	for _, v := range resp.Versions {
+		raw = append(raw, v.Version)
+	}
+	return parseVersions(raw)
+}
+
+// ModuleVersions returns every published version of the module
+func (c *Client) ModuleVersions(ctx context.Context, addr cdktf.ModuleAddress) ([]*hcversion.Version, error) {
+	svc, err := c.discover(ctx, addr.Hostname)
+	if err != nil {
+		return nil, err
+	}
+	if svc.ModulesV1 == "" {
+		return nil, errors.Newf("registry %q does not support modules", addr.Hostname)
+	}
+
+	var resp struct {
+		Modules []struct {
+			Versions []struct {
+				Version string `json:"version"`
+			} `json:"versions"`
+		} `json:"modules"`
+	}
+	if err := c.get(ctx, addr.Hostname, svc.ModulesV1, fmt.Sprintf("%s/%s/%s/versions", addr.Namespace, addr.Name, addr.Provider), &resp); err != nil {
+		return nil, errors.Wrapf(err, "list versions of module %q", addr.Package())
+	}
+	var raw []string
+	for _, m := range resp.Modules {
+		for _, v := range m.Versions {
+			raw = append(raw, v.Version)
+		}
+	}
+	return parseVersions(raw)
+}
+
+func (c *Client) discover(ctx context.Context, hostname string) (*services, error) {
+	var svc services
+	if err := c.get(ctx, hostname, "/.well-known/", "terraform.json", &svc); err != nil {
+		return nil, errors.Wrapf(err, "discover services of registry %q", hostname)
+	}
+	return &svc, nil
+}
+
+// get fetches the path relative to the service endpoint of the registry host and decodes the JSON response into v
+func (c *Client) get(ctx context.Context, hostname, service, path string, v any) error {
+	base := c.BaseURL
+	if base == "" {
+		base = "https://" + hostname
+	}
+	baseURL, err := url.Parse(strings.TrimSuffix(base, "/") + "/")
+	if err != nil {
+		return errors.Wrap(err, "parse registry url")
+	}
+	// the service endpoint may be an absolute url or a path relative to the host
+	serviceURL, err := baseURL.Parse(service)
+	if err != nil {
+		return errors.Wrapf(err, "parse service url %q", service)
+	}
+	if !strings.HasSuffix(serviceURL.Path, "/") {
+		serviceURL.Path += "/"
+	}
+	u, err := serviceURL.Parse(path)
+	if err != nil {
+		return errors.Wrapf(err, "parse url %q", path)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return errors.Wrap(err, "create request")
+	}
+	if token := c.Credentials[strings.ToLower(hostname)]; token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "GET %s", u)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Newf("GET %s: unexpected status %s", u, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return errors.Wrapf(err, "decode response of %s", u)
+	}
+	return nil
+}
+
+func parseVersions(raw []string) ([]*hcversion.Version, error) {
+	versions := make([]*hcversion.Version, 0, len(raw))
+	for _, r := range raw {
+		v, err := hcversion.NewVersion(r)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse version %q", r)
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// exactVersionPattern matches a full MAJOR.MINOR.PATCH version without a v prefix and with
+// an optional pre-release, i.e., the canonical form versions are published in
+var exactVersionPattern = regexp.MustCompile(`^(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(-[0-9A-Za-z.-]+)?$`)
+
+// IsExactVersion returns true if the version is a single exact version in its canonical form
+// rather than a constraint. Partial versions such as 4.69 or v4.69.1 must be resolved.
+func IsExactVersion(v string) bool {
+	return exactVersionPattern.MatchString(v)
+}
+
+// Resolve returns the newest version matching the terraform version constraint,
+// e.g., "~> 4.69". An empty constraint matches any version.
+// Pre-releases are only matched if the constraint explicitly mentions them.
+func Resolve(versions []*hcversion.Version, constraint string) (*hcversion.Version, error) {
+	var constraints hcversion.Constraints
+	if constraint != "" {
+		var err error
+		constraints, err = hcversion.NewConstraint(constraint)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse version constraint %q", constraint)
+		}
+	}
+
+	sorted := make([]*hcversion.Version, len(versions))
+	copy(sorted, versions)
+	sort.Sort(sort.Reverse(hcversion.Collection(sorted)))
+	for _, v := range sorted {
+		if constraints == nil {
+			if v.Prerelease() != "" {
+				continue
+			}
+			return v, nil
+		}
+		if constraints.Check(v) {
+			return v, nil
+		}
+	}
+	if constraint == "" {
+		return nil, errors.New("no stable version found")
+	}
+	return nil, errors.Newf("no version matches constraint %q", constraint)
+}
@@ -0,0 +1,129 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	hcversion "github.com/hashicorp/go-version"
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/cdktf-provider-gen/pkg/cdktf"
+)
+
+func newTestRegistry(t *testing.T) *Client {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"providers.v1": "/v1/providers/", "modules.v1": "/v1/modules/"}`))
+	})
+	mux.HandleFunc("/v1/providers/hashicorp/google/versions", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"versions": [{"version": "4.68.0"}, {"version": "4.69.1"}, {"version": "4.69.0"}, {"version": "5.0.0"}]}`))
+	})
+	mux.HandleFunc("/v1/modules/terraform-google-modules/kubernetes-engine/google/versions", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"modules": [{"versions": [{"version": "24.0.0"}, {"version": "24.1.0"}, {"version": "25.0.0-beta.1"}]}]}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return &Client{BaseURL: srv.URL}
+}
+
+func TestClient(t *testing.T) {
+	client := newTestRegistry(t)
+	ctx := context.Background()
+
+	t.Run("provider versions", func(t *testing.T) {
+		addr, err := cdktf.ParseProviderAddress("hashicorp/google")
+		require.NoError(t, err)
+		versions, err := client.ProviderVersions(ctx, addr)
+		require.NoError(t, err)
+		got, err := Resolve(versions, "~> 4.68")
+		require.NoError(t, err)
+		autogold.Expect("4.69.1").Equal(t, got.String())
+	})
+
+	t.Run("module versions", func(t *testing.T) {
+		addr, err := cdktf.ParseModuleAddress("terraform-google-modules/kubernetes-engine/google//modules/beta-private-cluster")
+		require.NoError(t, err)
+		versions, err := client.ModuleVersions(ctx, addr)
+		require.NoError(t, err)
+		got, err := Resolve(versions, "")
+		require.NoError(t, err)
+		autogold.Expect("24.1.0").Equal(t, got.String())
+	})
+
+	t.Run("credentials", func(t *testing.T) {
+		var got string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r.Header.Get("Authorization")
+			_, _ = w.Write([]byte(`{"modules.v1": "/v1/modules/"}`))
+		}))
+		t.Cleanup(srv.Close)
+		client := &Client{BaseURL: srv.URL, Credentials: map[string]string{"app.terraform.io": "secret-token"}}
+
+		addr, err := cdktf.ParseModuleAddress("app.terraform.io/example-corp/vpc/aws")
+		require.NoError(t, err)
+		_, _ = client.ModuleVersions(ctx, addr)
+		autogold.Expect("Bearer secret-token").Equal(t, got)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		addr, err := cdktf.ParseProviderAddress("hashicorp/unknown")
+		require.NoError(t, err)
+		_, err = client.ProviderVersions(ctx, addr)
+		require.Error(t, err)
+	})
+}
+
+func TestIsExactVersion(t *testing.T) {
+	got := make(map[string]bool)
+	for _, v := range []string{"4.69.1", "5.0.0-beta.1", "0.1.0", "4.69", "4", "v4.69.1", "~> 4.69", "= 4.69.1", "04.69.1", ""} {
+		got[v] = IsExactVersion(v)
+	}
+	autogold.Expect(map[string]bool{
+		"":             false,
+		"0.1.0":        true,
+		"04.69.1":      false,
+		"4":            false,
+		"4.69":         false,
+		"4.69.1":       true,
+		"5.0.0-beta.1": true,
+		"= 4.69.1":     false,
+		"v4.69.1":      false,
+		"~> 4.69":      false,
+	}).Equal(t, got)
+}
+
+func TestResolve(t *testing.T) {
+	var versions []*hcversion.Version
+	for _, v := range []string{"4.68.0", "4.69.0", "4.69.1", "5.0.0-beta.1", "5.0.0", "5.1.0"} {
+		versions = append(versions, hcversion.Must(hcversion.NewVersion(v)))
+	}
+
+	tests := []struct {
+		constraint string
+		want       autogold.Value
+		wantErr    autogold.Value
+	}{
+		{constraint: "", want: autogold.Expect("5.1.0")},
+		{constraint: "~> 4.68", want: autogold.Expect("4.69.1")},
+		{constraint: "~> 4.69.0", want: autogold.Expect("4.69.1")},
+		{constraint: ">= 4.0, < 5.0", want: autogold.Expect("4.69.1")},
+		{constraint: "= 4.68.0", want: autogold.Expect("4.68.0")},
+		{constraint: "5.0.0-beta.1", want: autogold.Expect("5.0.0-beta.1")},
+		{constraint: "~> 6.0", wantErr: autogold.Expect(`no version matches constraint "~> 6.0"`)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.constraint, func(t *testing.T) {
+			got, err := Resolve(versions, tc.constraint)
+			if tc.wantErr != nil {
+				require.Error(t, err)
+				tc.wantErr.Equal(t, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			tc.want.Equal(t, got.String())
+		})
+	}
+}